@@ -0,0 +1,6 @@
+CREATE TABLE profileinitialisationlock
+(
+    profile_id UUID PRIMARY KEY REFERENCES profile (id) ON DELETE CASCADE,
+    lock_id    UUID        NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,7 @@
+CREATE TABLE profilecategorytemplate
+(
+    profile_id       UUID PRIMARY KEY REFERENCES profile (id) ON DELETE CASCADE,
+    template_name    STRING NOT NULL,
+    template_version INT    NOT NULL,
+    initialised_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+ALTER TABLE category
+    ADD COLUMN sort_order INT NOT NULL DEFAULT 0;
+
+ALTER TABLE subcategory
+    ADD COLUMN sort_order INT NOT NULL DEFAULT 0;
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"categoryInitialiser/backfill"
+	"categoryInitialiser/models"
+	"categoryInitialiser/store"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runBackfill initialises every profile that has no categories with the default template and writes a JSON report of
+// the profiles that succeeded and failed. It exits with 1 if any profile failed.
+func runBackfill(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	concurrency := flags.Int("concurrency", 4, "maximum number of profiles initialised at once")
+	databaseUrl := flags.String("database-url", "", "CockroachDB connection string, overrides the configured connection string")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *concurrency < 1 {
+		fmt.Fprintln(stderr, "-concurrency must be at least 1")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := loadCommandConfig(ctx, *databaseUrl)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	// each initialisation holds one connection for its transaction and another for the profile lock
+	poolSize := int32(*concurrency) * 2
+	pool, err := store.NewCockroachDbPool(cfg.CockroachDbConnectionString, poolSize)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer pool.Close()
+
+	profilesRepository := &store.CockroachDbProfilesRepository{Connection: pool}
+	profiles, err := profilesRepository.ListUninitialisedProfiles(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to find uninitialised profiles: %v\n", err)
+		return 1
+	}
+
+	backfiller := &backfill.Backfiller{
+		Initialise: func(ctx context.Context, request models.IntialiseCategoriesRequest) (models.InitialiseCategoriesResponse, error) {
+			return initialiseCategories(ctx, cfg, pool, request)
+		},
+		Concurrency: *concurrency,
+	}
+
+	report := backfiller.Run(ctx, profiles)
+	if err := writeJson(stdout, report); err != nil {
+		fmt.Fprintf(stderr, "failed to write output: %v\n", err)
+		return 1
+	}
+
+	if len(report.Failed) > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,94 @@
+package backfill
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	"log"
+	"sync"
+)
+
+const defaultConcurrency = 4
+
+// Initialiser initialises the categories for a single request
+type Initialiser func(ctx context.Context, request models.IntialiseCategoriesRequest) (models.InitialiseCategoriesResponse, error)
+
+// Backfiller initialises profiles that were never initialised, a few at a time
+type Backfiller struct {
+	Initialise Initialiser
+	// Concurrency is the maximum number of profiles initialised at once
+	Concurrency int
+}
+
+// Run initialises each profile with the default template. A profile that fails is recorded in the report and doesn't
+// affect the others. Once ctx is done the remaining profiles are reported as failures without being attempted.
+func (b *Backfiller) Run(ctx context.Context, profiles []models.Profile) models.BackfillReport {
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	// results are kept in the same order as profiles so the report doesn't depend on which worker finished first
+	responses := make([]models.InitialiseCategoriesResponse, len(profiles))
+	errs := make([]error, len(profiles))
+
+	profileIndexes := make(chan int)
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for index := range profileIndexes {
+				responses[index], errs[index] = b.initialiseProfile(ctx, profiles[index])
+			}
+		}()
+	}
+
+	for index := range profiles {
+		profileIndexes <- index
+	}
+	close(profileIndexes)
+	workers.Wait()
+
+	report := models.BackfillReport{
+		ProfilesFound: len(profiles),
+		Succeeded:     make([]models.BackfillSuccess, 0),
+		Failed:        make([]models.BackfillFailure, 0),
+	}
+
+	for index, profile := range profiles {
+		if errs[index] != nil {
+			report.Failed = append(report.Failed, models.BackfillFailure{
+				ProfileId: profile.ProfileId,
+				UserId:    profile.UserId,
+				Error:     errs[index].Error(),
+			})
+			continue
+		}
+
+		report.Succeeded = append(report.Succeeded, models.BackfillSuccess{
+			ProfileId:                profile.ProfileId,
+			UserId:                   profile.UserId,
+			TemplateVersion:          responses[index].TemplateVersion,
+			InsertedCategoryCount:    responses[index].InsertedCategoryCount,
+			InsertedSubcategoryCount: responses[index].InsertedSubcategoryCount,
+		})
+	}
+
+	return report
+}
+
+func (b *Backfiller) initialiseProfile(ctx context.Context, profile models.Profile) (models.InitialiseCategoriesResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return models.InitialiseCategoriesResponse{}, err
+	}
+
+	response, err := b.Initialise(ctx, models.IntialiseCategoriesRequest{
+		UserId:    profile.UserId,
+		ProfileId: profile.ProfileId,
+	})
+	if err != nil {
+		log.Printf("failed to backfill profile %s: %v", profile.ProfileId, err)
+	}
+
+	return response, err
+}
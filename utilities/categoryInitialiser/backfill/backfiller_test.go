@@ -0,0 +1,81 @@
+//go:build !integrationTest
+
+package backfill
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingInitialiser struct {
+	mutex       sync.Mutex
+	running     int
+	maxRunning  int
+	failProfile string
+}
+
+func (initialiser *recordingInitialiser) initialise(_ context.Context, request models.IntialiseCategoriesRequest) (models.InitialiseCategoriesResponse, error) {
+	initialiser.mutex.Lock()
+	initialiser.running++
+	if initialiser.running > initialiser.maxRunning {
+		initialiser.maxRunning = initialiser.running
+	}
+	initialiser.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	initialiser.mutex.Lock()
+	initialiser.running--
+	initialiser.mutex.Unlock()
+
+	if request.ProfileId == initialiser.failProfile {
+		return models.InitialiseCategoriesResponse{}, errors.New("profile is locked by another initialisation")
+	}
+	return models.InitialiseCategoriesResponse{TemplateVersion: "au-default@1", InsertedCategoryCount: 9, InsertedSubcategoryCount: 40}, nil
+}
+
+func TestBackfiller(t *testing.T) {
+	profiles := []models.Profile{
+		{ProfileId: "profile1", UserId: "user1"},
+		{ProfileId: "profile2", UserId: "user2"},
+		{ProfileId: "profile3", UserId: "user3"},
+		{ProfileId: "profile4", UserId: "user4"},
+		{ProfileId: "profile5", UserId: "user5"},
+	}
+
+	t.Run("given profiles and one failing, when Run called, then failure isolated and the rest initialised", func(t *testing.T) {
+		initialiser := &recordingInitialiser{failProfile: "profile2"}
+		backfiller := &Backfiller{Initialise: initialiser.initialise, Concurrency: 2}
+
+		report := backfiller.Run(context.Background(), profiles)
+
+		assert.Equal(t, 5, report.ProfilesFound)
+		assert.Equal(t, []models.BackfillFailure{
+			{ProfileId: "profile2", UserId: "user2", Error: "profile is locked by another initialisation"},
+		}, report.Failed)
+		assert.Len(t, report.Succeeded, 4)
+		assert.Equal(t, models.BackfillSuccess{
+			ProfileId: "profile1", UserId: "user1", TemplateVersion: "au-default@1", InsertedCategoryCount: 9, InsertedSubcategoryCount: 40,
+		}, report.Succeeded[0])
+		assert.LessOrEqual(t, initialiser.maxRunning, 2)
+	})
+
+	t.Run("given cancelled context, when Run called, then every profile reported as failure without being initialised", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+		backfiller := &Backfiller{Initialise: initialiser.initialise, Concurrency: 2}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report := backfiller.Run(ctx, profiles)
+
+		assert.Len(t, report.Failed, 5)
+		assert.Empty(t, report.Succeeded)
+		assert.Equal(t, 0, initialiser.maxRunning)
+	})
+}
@@ -0,0 +1,20 @@
+//go:build !integrationTest
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBackfill(t *testing.T) {
+	t.Run("given concurrency below 1, when backfill run, then usage error returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runBackfill([]string{"-concurrency", "0"}, &stdout, &stderr)
+
+		assert.Equal(t, 2, exitCode)
+	})
+}
@@ -2,39 +2,41 @@ package category_provider
 
 import (
 	"categoryInitialiser/models"
+	"context"
 	"encoding/json"
 )
 
 type CategoryProvider interface {
-	GetCategories() ([]models.CategoryDto, error)
+	// GetCategories returns categories in the order they were authored in the template
+	GetCategories(ctx context.Context) ([]models.CategoryDto, error)
+	GetTemplateVersion() models.TemplateVersion
 }
 
-type JsonCategoryProvider struct {
-	CategoryJsonBytes map[string][]byte
+// TagProvider is implemented by category providers whose template can also seed tags
+type TagProvider interface {
+	GetTags(ctx context.Context) ([]string, error)
 }
 
-func (j *JsonCategoryProvider) convertCategoryMapToCategory(categoryType string, inputMap map[string][]string) (categories []models.CategoryDto) {
-	for categoryName, subcategories := range inputMap {
-		categories = append(categories, models.CategoryDto{
-			CategoryName:  categoryName,
-			CategoryType:  categoryType,
-			Subcategories: subcategories,
-		})
-	}
+// PayerPayeeProvider is implemented by category providers whose template can also seed payers and payees
+type PayerPayeeProvider interface {
+	GetPayerPayees(ctx context.Context) ([]models.PayerPayeeDto, error)
+}
 
-	return
+// JsonCategoryProvider reads categories from a JSON array of {"transactionType", "name", "subcategories"} objects
+type JsonCategoryProvider struct {
+	CategoryJsonBytes []byte
+	Template          models.TemplateVersion
 }
 
-func (j *JsonCategoryProvider) GetCategories() (categoryModels []models.CategoryDto, err error) {
-	for categoryType, stringBytes := range j.CategoryJsonBytes {
-		var categoriesMap = make(map[string][]string)
-		err := json.Unmarshal(stringBytes, &categoriesMap)
-		if err != nil {
-			return nil, err
-		}
+func (j *JsonCategoryProvider) GetTemplateVersion() models.TemplateVersion {
+	return j.Template
+}
 
-		categoryModels = append(categoryModels, j.convertCategoryMapToCategory(categoryType, categoriesMap)...)
+func (j *JsonCategoryProvider) GetCategories(context.Context) (categoryModels []models.CategoryDto, err error) {
+	err = json.Unmarshal(j.CategoryJsonBytes, &categoryModels)
+	if err != nil {
+		return nil, err
 	}
 
 	return
-}
\ No newline at end of file
+}
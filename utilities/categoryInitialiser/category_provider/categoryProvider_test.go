@@ -1,54 +1,65 @@
-// +build !integrationTest
+//go:build !integrationTest
 
 package category_provider
 
 import (
 	"categoryInitialiser/models"
+	"context"
 	_ "embed"
-	"github.com/stretchr/testify/assert"
-	"sort"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 //go:embed data/testCategories.json
 var testCategories []byte
 
 func TestJsonCategoryProvider_ParseCategories(t *testing.T) {
-	const expectedCategoryType = "TestCategoryType"
 	var parser = &JsonCategoryProvider{
-		CategoryJsonBytes: map[string][]byte{
-			expectedCategoryType: testCategories,
-		},
+		CategoryJsonBytes: testCategories,
 	}
 
-	categories, err := parser.GetCategories()
-	sort.Slice(categories, func(i, j int) bool {
-		return categories[i].CategoryName > categories[j].CategoryName
-	})
-
-	if err != nil {
-		return
-	}
+	categories, err := parser.GetCategories(context.Background())
+	assert.Nil(t, err)
 
 	var expectedCategories = []models.CategoryDto{
 		{
-			CategoryName: "Category1",
-			CategoryType: expectedCategoryType,
+			CategoryName: "Category2",
+			CategoryType: "expense",
 			Subcategories: []string{
-				"Subcategory1", "Subcategory2",
+				"Subcategory2", "Subcategory1",
 			},
 		},
 		{
-			CategoryName: "Category2",
-			CategoryType: expectedCategoryType,
+			CategoryName: "Category3",
+			CategoryType: "income",
+			Subcategories: []string{
+				"Subcategory1",
+			},
+		},
+		{
+			CategoryName: "Category1",
+			CategoryType: "expense",
 			Subcategories: []string{
 				"Subcategory1", "Subcategory2",
 			},
 		},
 	}
-	sort.Slice(expectedCategories, func(i, j int) bool {
-		return expectedCategories[i].CategoryName > expectedCategories[j].CategoryName
-	})
 
 	assert.Equal(t, expectedCategories, categories)
 }
+
+func TestJsonCategoryProvider_GetCategoriesIsDeterministic(t *testing.T) {
+	var parser = &JsonCategoryProvider{
+		CategoryJsonBytes: testCategories,
+	}
+
+	firstCategories, err := parser.GetCategories(context.Background())
+	assert.Nil(t, err)
+
+	for i := 0; i < 10; i++ {
+		categories, err := parser.GetCategories(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, firstCategories, categories)
+	}
+}
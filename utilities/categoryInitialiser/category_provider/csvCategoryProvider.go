@@ -0,0 +1,94 @@
+package category_provider
+
+import (
+	"bytes"
+	"categoryInitialiser/models"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+var csvHeader = []string{"transactionType", "category", "subcategory"}
+
+// CsvCategoryProvider reads categories from a CSV with a transactionType,category,subcategory header and one row per
+// subcategory. A row with an empty subcategory declares a category without adding a subcategory to it. Categories and
+// subcategories are returned in the order they first appear.
+type CsvCategoryProvider struct {
+	CategoryCsvBytes []byte
+	Template         models.TemplateVersion
+}
+
+func (c *CsvCategoryProvider) GetTemplateVersion() models.TemplateVersion {
+	return c.Template
+}
+
+func (c *CsvCategoryProvider) GetCategories(context.Context) ([]models.CategoryDto, error) {
+	reader := csv.NewReader(bytes.NewReader(c.CategoryCsvBytes))
+	reader.FieldsPerRecord = len(csvHeader)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("category template is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, column := range csvHeader {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), column) {
+			return nil, newTemplateError(1, "expected header %s", strings.Join(csvHeader, ","))
+		}
+	}
+
+	categoryModels := make([]models.CategoryDto, 0)
+	categoryIndexes := make(map[string]int)
+	subcategoryLines := make(map[string]int)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		transactionType, categoryName, subcategory := strings.TrimSpace(record[0]), strings.TrimSpace(record[1]), strings.TrimSpace(record[2])
+
+		if categoryName == "" {
+			return nil, newTemplateError(line, "row has no category")
+		}
+		if !isKnownTransactionType(transactionType) {
+			return nil, newTemplateError(line, "unknown transaction type %q", transactionType)
+		}
+
+		categoryKey := transactionType + "/" + categoryName
+		categoryIndex, ok := categoryIndexes[categoryKey]
+		if !ok {
+			categoryIndex = len(categoryModels)
+			categoryIndexes[categoryKey] = categoryIndex
+			categoryModels = append(categoryModels, models.CategoryDto{
+				CategoryName:  categoryName,
+				CategoryType:  transactionType,
+				Subcategories: []string{},
+			})
+		}
+
+		if subcategory == "" {
+			continue
+		}
+
+		subcategoryKey := categoryKey + "/" + subcategory
+		if firstLine, ok := subcategoryLines[subcategoryKey]; ok {
+			return nil, newTemplateError(line, "duplicate subcategory %q in %s category %q, first defined on line %d", subcategory, transactionType, categoryName, firstLine)
+		}
+		subcategoryLines[subcategoryKey] = line
+
+		categoryModels[categoryIndex].Subcategories = append(categoryModels[categoryIndex].Subcategories, subcategory)
+	}
+
+	return categoryModels, nil
+}
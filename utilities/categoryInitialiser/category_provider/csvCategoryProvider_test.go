@@ -0,0 +1,87 @@
+//go:build !integrationTest
+
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed data/testCategories.csv
+var testCategoriesCsv []byte
+
+func TestCsvCategoryProvider_GetCategories(t *testing.T) {
+	t.Run("given CSV template, when GetCategories called, then same categories as JSON template returned", func(t *testing.T) {
+		jsonCategories, err := (&JsonCategoryProvider{CategoryJsonBytes: testCategories}).GetCategories(context.Background())
+		assert.Nil(t, err)
+
+		csvCategories, err := (&CsvCategoryProvider{CategoryCsvBytes: testCategoriesCsv}).GetCategories(context.Background())
+		assert.Nil(t, err)
+
+		assert.Equal(t, jsonCategories, csvCategories)
+	})
+
+	t.Run("given row without subcategory, when GetCategories called, then category with no subcategories returned", func(t *testing.T) {
+		categories, err := (&CsvCategoryProvider{CategoryCsvBytes: []byte("transactionType,category,subcategory\nincome,Category1,\n")}).GetCategories(context.Background())
+		assert.Nil(t, err)
+
+		assert.Equal(t, []models.CategoryDto{
+			{CategoryName: "Category1", CategoryType: "income", Subcategories: []string{}},
+		}, categories)
+	})
+
+	t.Run("given invalid CSV template, when GetCategories called, then line numbered error returned", func(t *testing.T) {
+		testCases := []struct {
+			name         string
+			csv          string
+			expectedLine int
+		}{
+			{
+				name:         "missing header",
+				csv:          "expense,Category1,Subcategory1\n",
+				expectedLine: 1,
+			},
+			{
+				name:         "row without category",
+				csv:          "transactionType,category,subcategory\nexpense,Category1,Subcategory1\nexpense,,Subcategory1\n",
+				expectedLine: 3,
+			},
+			{
+				name:         "unknown transaction type",
+				csv:          "transactionType,category,subcategory\nexpense,Category1,Subcategory1\ntransfer,Category1,Subcategory1\n",
+				expectedLine: 3,
+			},
+			{
+				name:         "duplicate subcategory",
+				csv:          "transactionType,category,subcategory\nexpense,Category1,Subcategory1\nexpense,Category2,Subcategory1\nexpense,Category1,Subcategory1\n",
+				expectedLine: 4,
+			},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				_, err := (&CsvCategoryProvider{CategoryCsvBytes: []byte(testCase.csv)}).GetCategories(context.Background())
+
+				var templateError *TemplateError
+				if assert.True(t, errors.As(err, &templateError)) {
+					assert.Equal(t, testCase.expectedLine, templateError.Line)
+				}
+			})
+		}
+	})
+
+	t.Run("given row with wrong number of columns, when GetCategories called, then line numbered parse error returned", func(t *testing.T) {
+		_, err := (&CsvCategoryProvider{CategoryCsvBytes: []byte("transactionType,category,subcategory\nexpense,Category1\n")}).GetCategories(context.Background())
+
+		var parseError *csv.ParseError
+		if assert.True(t, errors.As(err, &parseError)) {
+			assert.Equal(t, 2, parseError.Line)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"categoryInitialiser/store"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrSourceProfileNotAccessible = errors.New("user does not belong to the source profile")
+
+// ProfileCategoryProvider copies the categories of an existing profile the requesting user belongs to
+type ProfileCategoryProvider struct {
+	Connection      store.DbConnection
+	UserId          string
+	SourceProfileId string
+	template        models.TemplateVersion
+}
+
+// GetTemplateVersion returns the template the source profile was initialised from, once GetCategories has been called
+func (p *ProfileCategoryProvider) GetTemplateVersion() models.TemplateVersion {
+	return p.template
+}
+
+func (p *ProfileCategoryProvider) GetCategories(ctx context.Context) ([]models.CategoryDto, error) {
+	var belongsToProfile bool
+	err := p.Connection.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM userprofile WHERE user_id = $1 AND profile_id = $2)`, p.UserId, p.SourceProfileId,
+	).Scan(&belongsToProfile)
+	if err != nil {
+		return nil, err
+	}
+	if !belongsToProfile {
+		return nil, ErrSourceProfileNotAccessible
+	}
+
+	err = p.Connection.QueryRow(ctx,
+		`SELECT template_name, template_version FROM profilecategorytemplate WHERE profile_id = $1`, p.SourceProfileId,
+	).Scan(&p.template.Name, &p.template.Version)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// the categories_and_subcategories view has neither transaction types nor sort orders, so read the tables directly
+	rows, err := p.Connection.Query(ctx,
+		`SELECT c.name, tt.name, sc.name
+		FROM category c
+		JOIN transactiontype tt ON tt.id = c.transaction_type_id
+		LEFT JOIN subcategory sc ON sc.category_id = c.id
+		WHERE c.profile_id = $1
+		ORDER BY tt.name, c.sort_order, c.name, sc.sort_order, sc.name`, p.SourceProfileId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categoryModels := make([]models.CategoryDto, 0)
+	var categoryName, transactionType string
+	var subcategoryName *string
+	_, err = pgx.ForEachRow(rows, []any{&categoryName, &transactionType, &subcategoryName}, func() error {
+		last := len(categoryModels) - 1
+		if last < 0 || categoryModels[last].CategoryName != categoryName || categoryModels[last].CategoryType != transactionType {
+			categoryModels = append(categoryModels, models.CategoryDto{
+				CategoryName:  categoryName,
+				CategoryType:  transactionType,
+				Subcategories: []string{},
+			})
+			last++
+		}
+
+		if subcategoryName != nil {
+			categoryModels[last].Subcategories = append(categoryModels[last].Subcategories, *subcategoryName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return categoryModels, nil
+}
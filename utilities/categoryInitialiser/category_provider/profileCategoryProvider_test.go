@@ -0,0 +1,82 @@
+//go:build integrationTest
+
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"categoryInitialiser/store"
+	"categoryInitialiser/test_utils"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileCategoryProvider_GetCategories(t *testing.T) {
+	t.Run("given user belongs to source profile, when GetCategories called, then source profile categories returned in sort order", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+		sourceProfileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+		cockroachDbHelpers.CreateUserProfile(userId, sourceProfileId)
+
+		sourceRepository := &store.CockroachDbCategoriesRepository{
+			Connection: conn,
+			UserId:     userId,
+			ProfileId:  sourceProfileId,
+		}
+		_, err := sourceRepository.SaveCategories(context.Background(), models.TemplateVersion{Name: "au-default", Version: 1}, []models.Category{
+			{CategoryName: "Category2", TransactionType: "expense", Subcategories: []string{"Subcategory2", "Subcategory1"}, SortOrder: 0},
+			{CategoryName: "Category1", TransactionType: "expense", Subcategories: []string{}, SortOrder: 1},
+			{CategoryName: "Category3", TransactionType: "income", Subcategories: []string{"Subcategory3"}, SortOrder: 0},
+		})
+		assert.Nil(t, err)
+
+		provider := &ProfileCategoryProvider{
+			Connection:      conn,
+			UserId:          userId,
+			SourceProfileId: sourceProfileId,
+		}
+
+		categories, err := provider.GetCategories(context.Background())
+		assert.Nil(t, err)
+
+		assert.Equal(t, []models.CategoryDto{
+			{CategoryName: "Category2", CategoryType: "expense", Subcategories: []string{"Subcategory2", "Subcategory1"}},
+			{CategoryName: "Category1", CategoryType: "expense", Subcategories: []string{}},
+			{CategoryName: "Category3", CategoryType: "income", Subcategories: []string{"Subcategory3"}},
+		}, categories)
+		assert.Equal(t, models.TemplateVersion{Name: "au-default", Version: 1}, provider.GetTemplateVersion())
+	})
+
+	t.Run("given user does not belong to source profile, when GetCategories called, then ErrSourceProfileNotAccessible returned", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+		otherUserId, _ := cockroachDbHelpers.CreateUser("golang_test_other")
+		sourceProfileId, _ := cockroachDbHelpers.CreateProfile("Other Profile")
+		cockroachDbHelpers.CreateUserProfile(otherUserId, sourceProfileId)
+
+		provider := &ProfileCategoryProvider{
+			Connection:      conn,
+			UserId:          userId,
+			SourceProfileId: sourceProfileId,
+		}
+
+		_, err := provider.GetCategories(context.Background())
+		assert.Equal(t, ErrSourceProfileNotAccessible, err)
+	})
+}
@@ -0,0 +1,30 @@
+package category_provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// knownTransactionTypes mirrors the rows of the transactiontype table
+var knownTransactionTypes = map[string]bool{
+	"expense": true,
+	"income":  true,
+}
+
+func isKnownTransactionType(transactionType string) bool {
+	return knownTransactionTypes[strings.ToLower(strings.TrimSpace(transactionType))]
+}
+
+// TemplateError describes a problem at a specific line of a category template
+type TemplateError struct {
+	Line    int
+	Message string
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+func newTemplateError(line int, format string, args ...any) *TemplateError {
+	return &TemplateError{Line: line, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,147 @@
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const DefaultTemplateName = "au-default"
+
+var ErrTemplateNotFound = errors.New("category template not found")
+
+//go:embed data/packs/*.json
+var embeddedTemplatePacks embed.FS
+
+// TemplatePack is a named, versioned set of categories, kept in the order they were authored
+type TemplatePack struct {
+	Name        string               `json:"name"`
+	Version     int                  `json:"version"`
+	Description string               `json:"description"`
+	Locale      string               `json:"locale"`
+	Categories  []models.CategoryDto `json:"categories"`
+	// Tags are optional and seeded alongside the categories
+	Tags []string `json:"tags"`
+	// PayerPayees are optional and seeded alongside the categories
+	PayerPayees []models.PayerPayeeDto `json:"payerPayees"`
+}
+
+func (p TemplatePack) TemplateVersion() models.TemplateVersion {
+	return models.TemplateVersion{Name: p.Name, Version: p.Version}
+}
+
+type TemplatePackRegistry struct {
+	// packs is keyed by pack name, with each pack's versions sorted in ascending order
+	packs map[string][]TemplatePack
+}
+
+// LoadEmbeddedTemplatePacks loads the template packs shipped in data/packs
+func LoadEmbeddedTemplatePacks() (*TemplatePackRegistry, error) {
+	packsDirectory, err := fs.Sub(embeddedTemplatePacks, "data/packs")
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadTemplatePacks(packsDirectory)
+}
+
+// LoadTemplatePacks loads every *.json template pack in the root of fsys
+func LoadTemplatePacks(fsys fs.FS) (*TemplatePackRegistry, error) {
+	fileNames, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &TemplatePackRegistry{packs: make(map[string][]TemplatePack)}
+
+	for _, fileName := range fileNames {
+		packBytes, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		var pack TemplatePack
+		if err = json.Unmarshal(packBytes, &pack); err != nil {
+			return nil, fmt.Errorf("failed to parse template pack %s: %w", path.Base(fileName), err)
+		}
+
+		if err = registry.add(pack); err != nil {
+			return nil, fmt.Errorf("invalid template pack %s: %w", path.Base(fileName), err)
+		}
+	}
+
+	return registry, nil
+}
+
+func (r *TemplatePackRegistry) add(pack TemplatePack) error {
+	if pack.Name == "" || pack.Version < 1 {
+		return errors.New("template pack must have a name and a version of at least 1")
+	}
+
+	for _, existingPack := range r.packs[pack.Name] {
+		if existingPack.Version == pack.Version {
+			return fmt.Errorf("duplicate template pack %s", pack.TemplateVersion())
+		}
+	}
+
+	r.packs[pack.Name] = append(r.packs[pack.Name], pack)
+	sort.Slice(r.packs[pack.Name], func(i, j int) bool {
+		return r.packs[pack.Name][i].Version < r.packs[pack.Name][j].Version
+	})
+
+	return nil
+}
+
+// Get returns the pack identified by template, either "name" for the latest version of a pack or "name@version".
+// An empty template returns the latest version of the default pack.
+func (r *TemplatePackRegistry) Get(template string) (TemplatePack, error) {
+	if template == "" {
+		template = DefaultTemplateName
+	}
+
+	name, versionString, hasVersion := strings.Cut(template, "@")
+	versions := r.packs[name]
+	if len(versions) == 0 {
+		return TemplatePack{}, fmt.Errorf("%w: %s", ErrTemplateNotFound, template)
+	}
+
+	if !hasVersion {
+		return versions[len(versions)-1], nil
+	}
+
+	version, err := strconv.Atoi(versionString)
+	if err != nil {
+		return TemplatePack{}, fmt.Errorf("invalid version in template %q: %w", template, err)
+	}
+
+	for _, pack := range versions {
+		if pack.Version == version {
+			return pack, nil
+		}
+	}
+
+	return TemplatePack{}, fmt.Errorf("%w: %s", ErrTemplateNotFound, template)
+}
+
+// List returns every version of every pack, ordered by name and then version
+func (r *TemplatePackRegistry) List() []TemplatePack {
+	names := make([]string, 0, len(r.packs))
+	for name := range r.packs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	packs := make([]TemplatePack, 0)
+	for _, name := range names {
+		packs = append(packs, r.packs[name]...)
+	}
+
+	return packs
+}
@@ -0,0 +1,35 @@
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"context"
+)
+
+type TemplatePackCategoryProvider struct {
+	Pack TemplatePack
+}
+
+func (t *TemplatePackCategoryProvider) GetCategories(context.Context) ([]models.CategoryDto, error) {
+	categoryModels := make([]models.CategoryDto, len(t.Pack.Categories))
+	copy(categoryModels, t.Pack.Categories)
+
+	return categoryModels, nil
+}
+
+func (t *TemplatePackCategoryProvider) GetTemplateVersion() models.TemplateVersion {
+	return t.Pack.TemplateVersion()
+}
+
+func (t *TemplatePackCategoryProvider) GetTags(context.Context) ([]string, error) {
+	tags := make([]string, len(t.Pack.Tags))
+	copy(tags, t.Pack.Tags)
+
+	return tags, nil
+}
+
+func (t *TemplatePackCategoryProvider) GetPayerPayees(context.Context) ([]models.PayerPayeeDto, error) {
+	payerPayees := make([]models.PayerPayeeDto, len(t.Pack.PayerPayees))
+	copy(payerPayees, t.Pack.PayerPayees)
+
+	return payerPayees, nil
+}
@@ -0,0 +1,162 @@
+//go:build !integrationTest
+
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEmbeddedTemplatePacks(t *testing.T) {
+	registry, err := LoadEmbeddedTemplatePacks()
+	assert.Nil(t, err)
+
+	for _, template := range []string{"au-default@1", "au-default@2", "us-default@1", "us-default@2", "minimal@1"} {
+		pack, err := registry.Get(template)
+		assert.Nil(t, err)
+		assert.Equal(t, template, pack.TemplateVersion().String())
+		assert.NotEmpty(t, pack.Categories)
+	}
+
+	defaultPack, err := registry.Get("")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultTemplateName, defaultPack.Name)
+
+	var categoryNames []string
+	for _, category := range defaultPack.Categories {
+		categoryNames = append(categoryNames, category.CategoryName)
+	}
+	assert.Equal(t, []string{
+		"Home & Utilities", "Transport", "Insurance & Financial", "Groceries", "Personal", "Medical", "Entertainment", "Eating Out", "Income",
+	}, categoryNames)
+	assert.Equal(t, []string{"Salary", "Bank Interest", "Investments", "Sale"}, defaultPack.Categories[8].Subcategories)
+}
+
+func TestTemplatePackRegistry_Get(t *testing.T) {
+	registry, err := LoadTemplatePacks(fstest.MapFS{
+		"test@1.json": {Data: []byte(`{"name": "test", "version": 1, "categories": [{"transactionType": "expense", "name": "Category1", "subcategories": ["Subcategory1"]}]}`)},
+		"test@2.json": {Data: []byte(`{"name": "test", "version": 2, "categories": [{"transactionType": "expense", "name": "Category2", "subcategories": ["Subcategory2"]}]}`)},
+	})
+	assert.Nil(t, err)
+
+	t.Run("given template name without version, when Get called, then latest version returned", func(t *testing.T) {
+		pack, err := registry.Get("test")
+		assert.Nil(t, err)
+		assert.Equal(t, models.TemplateVersion{Name: "test", Version: 2}, pack.TemplateVersion())
+	})
+
+	t.Run("given template name with version, when Get called, then that version returned", func(t *testing.T) {
+		pack, err := registry.Get("test@1")
+		assert.Nil(t, err)
+		assert.Equal(t, []models.CategoryDto{
+			{CategoryName: "Category1", CategoryType: "expense", Subcategories: []string{"Subcategory1"}},
+		}, pack.Categories)
+	})
+
+	t.Run("given unknown template, when Get called, then ErrTemplateNotFound returned", func(t *testing.T) {
+		for _, template := range []string{"unknown", "test@3"} {
+			_, err := registry.Get(template)
+			assert.True(t, errors.Is(err, ErrTemplateNotFound))
+		}
+	})
+
+	t.Run("given invalid version, when Get called, then error returned", func(t *testing.T) {
+		_, err := registry.Get("test@latest")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestLoadTemplatePacks_DuplicateVersion(t *testing.T) {
+	_, err := LoadTemplatePacks(fstest.MapFS{
+		"a.json": {Data: []byte(`{"name": "test", "version": 1}`)},
+		"b.json": {Data: []byte(`{"name": "test", "version": 1}`)},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestTemplatePackCategoryProvider_GetCategories(t *testing.T) {
+	var provider = &TemplatePackCategoryProvider{
+		Pack: TemplatePack{
+			Name:    "test",
+			Version: 1,
+			Categories: []models.CategoryDto{
+				{CategoryName: "Category2", CategoryType: "income", Subcategories: []string{"Subcategory3"}},
+				{CategoryName: "Category1", CategoryType: "expense", Subcategories: []string{"Subcategory2", "Subcategory1"}},
+			},
+		},
+	}
+
+	categories, err := provider.GetCategories(context.Background())
+	assert.Nil(t, err)
+
+	assert.Equal(t, []models.CategoryDto{
+		{CategoryName: "Category2", CategoryType: "income", Subcategories: []string{"Subcategory3"}},
+		{CategoryName: "Category1", CategoryType: "expense", Subcategories: []string{"Subcategory2", "Subcategory1"}},
+	}, categories)
+	assert.Equal(t, models.TemplateVersion{Name: "test", Version: 1}, provider.GetTemplateVersion())
+}
+
+func TestTemplatePackCategoryProvider_GetTags(t *testing.T) {
+	t.Run("given pack with tags, when GetTags called, then tags returned", func(t *testing.T) {
+		var provider = &TemplatePackCategoryProvider{
+			Pack: TemplatePack{Name: "test", Version: 1, Tags: []string{"Holiday", "Business"}},
+		}
+
+		tags, err := provider.GetTags(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Holiday", "Business"}, tags)
+	})
+
+	t.Run("given pack without tags, when GetTags called, then no tags returned", func(t *testing.T) {
+		var provider = &TemplatePackCategoryProvider{
+			Pack: TemplatePack{Name: "test", Version: 1},
+		}
+
+		tags, err := provider.GetTags(context.Background())
+		assert.Nil(t, err)
+		assert.Empty(t, tags)
+	})
+}
+
+func TestLoadTemplatePacks_InvalidPayerPayeeType(t *testing.T) {
+	registry, err := LoadTemplatePacks(fstest.MapFS{
+		"a.json": {Data: []byte(`{"name": "test", "version": 1, "payerPayees": [{"name": "Coles", "type": "merchant"}]}`)},
+	})
+	assert.Nil(t, err)
+
+	pack, err := registry.Get("test@1")
+	assert.Nil(t, err)
+	assert.Equal(t, []ValidationIssue{
+		{List: "payerPayees", Name: "Coles", Message: `unknown type "merchant", expected payer or payee`},
+	}, NewTemplateValidator().ValidatePack(pack))
+}
+
+func TestTemplatePackCategoryProvider_GetPayerPayees(t *testing.T) {
+	t.Run("given pack with payers and payees, when GetPayerPayees called, then payers and payees returned", func(t *testing.T) {
+		var provider = &TemplatePackCategoryProvider{
+			Pack: TemplatePack{Name: "test", Version: 1, PayerPayees: []models.PayerPayeeDto{
+				{Name: "Coles", Type: "payee"},
+				{Name: "Employer", Type: "payer"},
+			}},
+		}
+
+		payerPayees, err := provider.GetPayerPayees(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, []models.PayerPayeeDto{{Name: "Coles", Type: "payee"}, {Name: "Employer", Type: "payer"}}, payerPayees)
+	})
+
+	t.Run("given pack without payers and payees, when GetPayerPayees called, then none returned", func(t *testing.T) {
+		var provider = &TemplatePackCategoryProvider{
+			Pack: TemplatePack{Name: "test", Version: 1},
+		}
+
+		payerPayees, err := provider.GetPayerPayees(context.Background())
+		assert.Nil(t, err)
+		assert.Empty(t, payerPayees)
+	})
+}
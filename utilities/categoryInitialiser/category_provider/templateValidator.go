@@ -0,0 +1,200 @@
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxNameLength matches the VARCHAR(255) name columns of the category, subcategory and payerpayee tables, tags are held
+// to the same length
+const maxNameLength = 255
+
+// payerPayeeTypes are the names of the rows in the payerpayeetype table
+var payerPayeeTypes = []string{"payer", "payee"}
+
+// reservedNames are names the MoneyMate apps use for their own groupings and filters
+var reservedNames = []string{"Uncategorised", "Uncategorized", "All"}
+
+type ValidationIssue struct {
+	TransactionType string
+	Category        string
+	Subcategory     string
+	// List is set to "tags" or "payerPayees" for issues outside the categories, with the name of the entry in Name
+	List    string
+	Name    string
+	Message string
+}
+
+func (i ValidationIssue) String() string {
+	location := i.TransactionType
+	if i.List != "" {
+		location = i.List
+		if i.Name != "" {
+			location += " > " + i.Name
+		}
+	}
+	if i.Category != "" {
+		location += " > " + i.Category
+	}
+	if i.Subcategory != "" {
+		location += " > " + i.Subcategory
+	}
+
+	return fmt.Sprintf("%s: %s", location, i.Message)
+}
+
+type TemplateValidator struct {
+	TransactionTypes []string
+	ReservedNames    []string
+	MaxNameLength    int
+}
+
+func NewTemplateValidator() *TemplateValidator {
+	transactionTypes := make([]string, 0, len(knownTransactionTypes))
+	for transactionType := range knownTransactionTypes {
+		transactionTypes = append(transactionTypes, transactionType)
+	}
+
+	return &TemplateValidator{
+		TransactionTypes: transactionTypes,
+		ReservedNames:    reservedNames,
+		MaxNameLength:    maxNameLength,
+	}
+}
+
+// normaliseName folds case and whitespace so names that would look the same to a user compare equal
+func normaliseName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// nameMessages returns the issues with a name that apply to every kind of entry in a template
+func (v *TemplateValidator) nameMessages(name string) (messages []string) {
+	if strings.TrimSpace(name) == "" {
+		messages = append(messages, "name is empty")
+	}
+	if utf8.RuneCountInString(name) > v.MaxNameLength {
+		messages = append(messages, fmt.Sprintf("name is longer than %d characters", v.MaxNameLength))
+	}
+
+	return messages
+}
+
+// ValidatePack returns every issue found in the categories, tags and payers and payees of pack, in that order
+func (v *TemplateValidator) ValidatePack(pack TemplatePack) (issues []ValidationIssue) {
+	issues = append(issues, v.Validate(pack.Categories)...)
+	issues = append(issues, v.ValidateTags(pack.Tags)...)
+	issues = append(issues, v.ValidatePayerPayees(pack.PayerPayees)...)
+
+	return issues
+}
+
+// Validate returns every issue found in categories, in template order
+func (v *TemplateValidator) Validate(categories []models.CategoryDto) (issues []ValidationIssue) {
+	transactionTypes := make(map[string]bool)
+	for _, transactionType := range v.TransactionTypes {
+		transactionTypes[normaliseName(transactionType)] = true
+	}
+
+	reserved := make(map[string]bool)
+	for _, name := range v.ReservedNames {
+		reserved[normaliseName(name)] = true
+	}
+
+	seenCategories := make(map[string]bool)
+
+	for _, category := range categories {
+		issue := func(subcategory string, format string, args ...any) {
+			issues = append(issues, ValidationIssue{
+				TransactionType: category.CategoryType,
+				Category:        category.CategoryName,
+				Subcategory:     subcategory,
+				Message:         fmt.Sprintf(format, args...),
+			})
+		}
+
+		checkName := func(name string, subcategory string) {
+			for _, message := range v.nameMessages(name) {
+				issue(subcategory, message)
+			}
+			if reserved[normaliseName(name)] {
+				issue(subcategory, "%q is a reserved name", name)
+			}
+		}
+
+		if !transactionTypes[normaliseName(category.CategoryType)] {
+			issue("", "unknown transaction type %q", category.CategoryType)
+		}
+
+		checkName(category.CategoryName, "")
+
+		categoryKey := normaliseName(category.CategoryType) + "/" + normaliseName(category.CategoryName)
+		if seenCategories[categoryKey] {
+			issue("", "duplicate category")
+		}
+		seenCategories[categoryKey] = true
+
+		if len(category.Subcategories) == 0 {
+			issue("", "category has no subcategories")
+		}
+
+		seenSubcategories := make(map[string]bool)
+		for _, subcategory := range category.Subcategories {
+			checkName(subcategory, subcategory)
+
+			if seenSubcategories[normaliseName(subcategory)] {
+				issue(subcategory, "duplicate subcategory")
+			}
+			seenSubcategories[normaliseName(subcategory)] = true
+		}
+	}
+
+	return issues
+}
+
+// ValidateTags returns every issue found in tags, in template order
+func (v *TemplateValidator) ValidateTags(tags []string) (issues []ValidationIssue) {
+	seenTags := make(map[string]bool)
+
+	for _, tag := range tags {
+		for _, message := range v.nameMessages(tag) {
+			issues = append(issues, ValidationIssue{List: "tags", Name: tag, Message: message})
+		}
+
+		if seenTags[normaliseName(tag)] {
+			issues = append(issues, ValidationIssue{List: "tags", Name: tag, Message: "duplicate tag"})
+		}
+		seenTags[normaliseName(tag)] = true
+	}
+
+	return issues
+}
+
+// ValidatePayerPayees returns every issue found in payerPayees, in template order. A payer and a payee can share a name.
+func (v *TemplateValidator) ValidatePayerPayees(payerPayees []models.PayerPayeeDto) (issues []ValidationIssue) {
+	seenPayerPayees := make(map[string]bool)
+
+	for _, payerPayee := range payerPayees {
+		issue := func(format string, args ...any) {
+			issues = append(issues, ValidationIssue{List: "payerPayees", Name: payerPayee.Name, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if !slices.Contains(payerPayeeTypes, payerPayee.Type) {
+			issue("unknown type %q, expected %s", payerPayee.Type, strings.Join(payerPayeeTypes, " or "))
+		}
+
+		for _, message := range v.nameMessages(payerPayee.Name) {
+			issue(message)
+		}
+
+		payerPayeeKey := payerPayee.Type + "/" + normaliseName(payerPayee.Name)
+		if seenPayerPayees[payerPayeeKey] {
+			issue("duplicate %s", payerPayee.Type)
+		}
+		seenPayerPayees[payerPayeeKey] = true
+	}
+
+	return issues
+}
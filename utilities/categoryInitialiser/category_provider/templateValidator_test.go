@@ -0,0 +1,95 @@
+//go:build !integrationTest
+
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateValidator_Validate(t *testing.T) {
+	t.Run("given embedded template packs, when Validate called, then no issues returned", func(t *testing.T) {
+		registry, err := LoadEmbeddedTemplatePacks()
+		assert.Nil(t, err)
+
+		for _, pack := range registry.List() {
+			assert.Empty(t, NewTemplateValidator().ValidatePack(pack), pack.TemplateVersion().String())
+		}
+	})
+
+	t.Run("given invalid categories, when Validate called, then every issue returned in template order", func(t *testing.T) {
+		issues := NewTemplateValidator().Validate([]models.CategoryDto{
+			{CategoryName: "Category1", CategoryType: "expense", Subcategories: []string{"Subcategory1", " subcategory1 "}},
+			{CategoryName: "  category1 ", CategoryType: "Expense", Subcategories: []string{"Subcategory1"}},
+			{CategoryName: "Category1", CategoryType: "income", Subcategories: []string{}},
+			{CategoryName: "Category2", CategoryType: "transfer", Subcategories: []string{strings.Repeat("a", 256)}},
+			{CategoryName: "Uncategorised", CategoryType: "expense", Subcategories: []string{""}},
+		})
+
+		assert.Equal(t, []ValidationIssue{
+			{TransactionType: "expense", Category: "Category1", Subcategory: " subcategory1 ", Message: "duplicate subcategory"},
+			{TransactionType: "Expense", Category: "  category1 ", Message: "duplicate category"},
+			{TransactionType: "income", Category: "Category1", Message: "category has no subcategories"},
+			{TransactionType: "transfer", Category: "Category2", Message: `unknown transaction type "transfer"`},
+			{TransactionType: "transfer", Category: "Category2", Subcategory: strings.Repeat("a", 256), Message: "name is longer than 255 characters"},
+			{TransactionType: "expense", Category: "Uncategorised", Message: `"Uncategorised" is a reserved name`},
+			{TransactionType: "expense", Category: "Uncategorised", Message: "name is empty"},
+		}, issues)
+	})
+
+	t.Run("given transaction types from database, when Validate called, then only those transaction types accepted", func(t *testing.T) {
+		validator := NewTemplateValidator()
+		validator.TransactionTypes = []string{"expense"}
+
+		issues := validator.Validate([]models.CategoryDto{
+			{CategoryName: "Category1", CategoryType: "income", Subcategories: []string{"Subcategory1"}},
+		})
+
+		assert.Equal(t, []ValidationIssue{
+			{TransactionType: "income", Category: "Category1", Message: `unknown transaction type "income"`},
+		}, issues)
+	})
+}
+
+func TestTemplateValidator_ValidateTags(t *testing.T) {
+	t.Run("given invalid tags, when ValidateTags called, then every issue returned in template order", func(t *testing.T) {
+		issues := NewTemplateValidator().ValidateTags([]string{"Holiday", " holiday ", "", strings.Repeat("a", 256)})
+
+		assert.Equal(t, []ValidationIssue{
+			{List: "tags", Name: " holiday ", Message: "duplicate tag"},
+			{List: "tags", Name: "", Message: "name is empty"},
+			{List: "tags", Name: strings.Repeat("a", 256), Message: "name is longer than 255 characters"},
+		}, issues)
+	})
+}
+
+func TestTemplateValidator_ValidatePayerPayees(t *testing.T) {
+	t.Run("given invalid payers and payees, when ValidatePayerPayees called, then every issue returned in template order", func(t *testing.T) {
+		issues := NewTemplateValidator().ValidatePayerPayees([]models.PayerPayeeDto{
+			{Name: "Coles", Type: "payee"},
+			{Name: "Coles", Type: "payer"},
+			{Name: "coles ", Type: "payee"},
+			{Name: "Employer", Type: "employer"},
+			{Name: " ", Type: "payer"},
+			{Name: strings.Repeat("a", 256), Type: "payee"},
+		})
+
+		assert.Equal(t, []ValidationIssue{
+			{List: "payerPayees", Name: "coles ", Message: "duplicate payee"},
+			{List: "payerPayees", Name: "Employer", Message: `unknown type "employer", expected payer or payee`},
+			{List: "payerPayees", Name: " ", Message: "name is empty"},
+			{List: "payerPayees", Name: strings.Repeat("a", 256), Message: "name is longer than 255 characters"},
+		}, issues)
+	})
+}
+
+func TestValidationIssue_String(t *testing.T) {
+	issue := ValidationIssue{TransactionType: "expense", Category: "Category1", Subcategory: "Subcategory1", Message: "duplicate subcategory"}
+	assert.Equal(t, "expense > Category1 > Subcategory1: duplicate subcategory", issue.String())
+
+	issue = ValidationIssue{List: "tags", Name: "Holiday", Message: "duplicate tag"}
+	assert.Equal(t, "tags > Holiday: duplicate tag", issue.String())
+}
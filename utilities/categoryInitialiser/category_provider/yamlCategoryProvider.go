@@ -0,0 +1,96 @@
+package category_provider
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	"errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YamlCategoryProvider reads categories from a YAML sequence of {transactionType, name, subcategories} mappings
+type YamlCategoryProvider struct {
+	CategoryYamlBytes []byte
+	Template          models.TemplateVersion
+}
+
+type yamlCategory struct {
+	TransactionType string   `yaml:"transactionType"`
+	Name            string   `yaml:"name"`
+	Subcategories   []string `yaml:"subcategories"`
+}
+
+func (y *YamlCategoryProvider) GetTemplateVersion() models.TemplateVersion {
+	return y.Template
+}
+
+func (y *YamlCategoryProvider) GetCategories(context.Context) ([]models.CategoryDto, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(y.CategoryYamlBytes, &document); err != nil {
+		return nil, err
+	}
+
+	if len(document.Content) == 0 {
+		return nil, errors.New("category template is empty")
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.SequenceNode {
+		return nil, newTemplateError(root.Line, "expected a list of categories")
+	}
+
+	categoryModels := make([]models.CategoryDto, 0, len(root.Content))
+	seenCategories := make(map[string]int)
+
+	for _, node := range root.Content {
+		var category yamlCategory
+		if err := node.Decode(&category); err != nil {
+			return nil, newTemplateError(node.Line, "malformed category: %v", err)
+		}
+
+		if category.Name == "" {
+			return nil, newTemplateError(node.Line, "category has no name")
+		}
+		if !isKnownTransactionType(category.TransactionType) {
+			return nil, newTemplateError(node.Line, "unknown transaction type %q", category.TransactionType)
+		}
+
+		categoryKey := category.TransactionType + "/" + category.Name
+		if firstLine, ok := seenCategories[categoryKey]; ok {
+			return nil, newTemplateError(node.Line, "duplicate %s category %q, first defined on line %d", category.TransactionType, category.Name, firstLine)
+		}
+		seenCategories[categoryKey] = node.Line
+
+		seenSubcategories := make(map[string]bool)
+		for _, subcategoryNode := range subcategoryNodes(node) {
+			if seenSubcategories[subcategoryNode.Value] {
+				return nil, newTemplateError(subcategoryNode.Line, "duplicate subcategory %q in category %q", subcategoryNode.Value, category.Name)
+			}
+			seenSubcategories[subcategoryNode.Value] = true
+		}
+
+		// a category without subcategories is returned with an empty list, the same as the other providers
+		if category.Subcategories == nil {
+			category.Subcategories = []string{}
+		}
+
+		categoryModels = append(categoryModels, models.CategoryDto{
+			CategoryName:  category.Name,
+			CategoryType:  category.TransactionType,
+			Subcategories: category.Subcategories,
+		})
+	}
+
+	return categoryModels, nil
+}
+
+// subcategoryNodes returns the nodes of a category's subcategories so errors can point at the offending line
+func subcategoryNodes(categoryNode *yaml.Node) []*yaml.Node {
+	for i := 0; i+1 < len(categoryNode.Content); i += 2 {
+		if categoryNode.Content[i].Value == "subcategories" {
+			return categoryNode.Content[i+1].Content
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+//go:build !integrationTest
+
+package category_provider
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed data/testCategories.yaml
+var testCategoriesYaml []byte
+
+func TestYamlCategoryProvider_GetCategories(t *testing.T) {
+	t.Run("given YAML template, when GetCategories called, then same categories as JSON template returned", func(t *testing.T) {
+		jsonCategories, err := (&JsonCategoryProvider{CategoryJsonBytes: testCategories}).GetCategories(context.Background())
+		assert.Nil(t, err)
+
+		yamlCategories, err := (&YamlCategoryProvider{CategoryYamlBytes: testCategoriesYaml}).GetCategories(context.Background())
+		assert.Nil(t, err)
+
+		assert.Equal(t, jsonCategories, yamlCategories)
+	})
+
+	t.Run("given category without subcategories, when GetCategories called, then same categories as CSV template returned", func(t *testing.T) {
+		csvCategories, err := (&CsvCategoryProvider{CategoryCsvBytes: []byte("transactionType,category,subcategory\nexpense,Category1,\nincome,Category2,Subcategory1\n")}).GetCategories(context.Background())
+		assert.Nil(t, err)
+
+		yamlCategories, err := (&YamlCategoryProvider{CategoryYamlBytes: []byte("- transactionType: expense\n  name: Category1\n- transactionType: income\n  name: Category2\n  subcategories:\n    - Subcategory1\n")}).GetCategories(context.Background())
+		assert.Nil(t, err)
+
+		assert.Equal(t, csvCategories, yamlCategories)
+		assert.Equal(t, []string{}, yamlCategories[0].Subcategories)
+	})
+
+	t.Run("given invalid YAML template, when GetCategories called, then line numbered error returned", func(t *testing.T) {
+		testCases := []struct {
+			name         string
+			yaml         string
+			expectedLine int
+		}{
+			{
+				name:         "malformed category",
+				yaml:         "- transactionType: expense\n  name: Category1\n- transactionType: expense\n  name: [Category2]\n",
+				expectedLine: 3,
+			},
+			{
+				name:         "unknown transaction type",
+				yaml:         "- transactionType: expense\n  name: Category1\n- transactionType: transfer\n  name: Category2\n",
+				expectedLine: 3,
+			},
+			{
+				name:         "duplicate category",
+				yaml:         "- transactionType: expense\n  name: Category1\n- transactionType: expense\n  name: Category1\n",
+				expectedLine: 3,
+			},
+			{
+				name:         "duplicate subcategory",
+				yaml:         "- transactionType: expense\n  name: Category1\n  subcategories:\n    - Subcategory1\n    - Subcategory1\n",
+				expectedLine: 5,
+			},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				_, err := (&YamlCategoryProvider{CategoryYamlBytes: []byte(testCase.yaml)}).GetCategories(context.Background())
+
+				var templateError *TemplateError
+				if assert.True(t, errors.As(err, &templateError)) {
+					assert.Equal(t, testCase.expectedLine, templateError.Line)
+				}
+			})
+		}
+	})
+}
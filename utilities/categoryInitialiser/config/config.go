@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	CockroachDbConnectionStringKey = "cockroachDbConnectionString"
+	CockroachDbPoolSizeKey         = "cockroachDbPoolSize"
+	DefaultTemplateKey             = "defaultTemplate"
+	ProfileLockLeaseDurationKey    = "profileLockLeaseDuration"
+	ProfileLockWaitTimeoutKey      = "profileLockWaitTimeout"
+)
+
+const (
+	// MinimumCockroachDbPoolSize leaves a connection for the profile lock while an initialisation's transaction holds
+	// another
+	MinimumCockroachDbPoolSize = 2
+
+	defaultCockroachDbPoolSize      = MinimumCockroachDbPoolSize
+	defaultProfileLockLeaseDuration = 60 * time.Second
+	defaultProfileLockWaitTimeout   = 20 * time.Second
+)
+
+var ErrMissingConnectionString = errors.New("no CockroachDb connection string configured")
+
+type Config struct {
+	CockroachDbConnectionString string
+	CockroachDbPoolSize         int32
+	// DefaultTemplate is used for requests that don't select a template, empty means the latest au-default pack
+	DefaultTemplate          string
+	ProfileLockLeaseDuration time.Duration
+	ProfileLockWaitTimeout   time.Duration
+}
+
+// Load reads the initialiser's configuration from provider, falling back to defaults for optional values
+func Load(ctx context.Context, provider Provider) (Config, error) {
+	config := Config{
+		CockroachDbPoolSize:      defaultCockroachDbPoolSize,
+		ProfileLockLeaseDuration: defaultProfileLockLeaseDuration,
+		ProfileLockWaitTimeout:   defaultProfileLockWaitTimeout,
+	}
+
+	connectionString, found, err := provider.Lookup(ctx, CockroachDbConnectionStringKey)
+	if err != nil {
+		return Config{}, err
+	}
+	if !found || connectionString == "" {
+		return Config{}, ErrMissingConnectionString
+	}
+	config.CockroachDbConnectionString = connectionString
+
+	if err := lookupPoolSize(ctx, provider, CockroachDbPoolSizeKey, &config.CockroachDbPoolSize); err != nil {
+		return Config{}, err
+	}
+
+	if config.DefaultTemplate, _, err = provider.Lookup(ctx, DefaultTemplateKey); err != nil {
+		return Config{}, err
+	}
+
+	if err := lookupDuration(ctx, provider, ProfileLockLeaseDurationKey, &config.ProfileLockLeaseDuration); err != nil {
+		return Config{}, err
+	}
+
+	if err := lookupDuration(ctx, provider, ProfileLockWaitTimeoutKey, &config.ProfileLockWaitTimeout); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+func lookupPoolSize(ctx context.Context, provider Provider, key string, poolSize *int32) error {
+	value, found, err := provider.Lookup(ctx, key)
+	if err != nil || !found {
+		return err
+	}
+
+	parsedPoolSize, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || parsedPoolSize < MinimumCockroachDbPoolSize {
+		return fmt.Errorf("%s must be an integer of at least %d, got %q", key, MinimumCockroachDbPoolSize, value)
+	}
+
+	*poolSize = int32(parsedPoolSize)
+	return nil
+}
+
+func lookupDuration(ctx context.Context, provider Provider, key string, duration *time.Duration) error {
+	value, found, err := provider.Lookup(ctx, key)
+	if err != nil || !found {
+		return err
+	}
+
+	parsedDuration, err := time.ParseDuration(value)
+	if err != nil || parsedDuration <= 0 {
+		return fmt.Errorf("%s must be a positive duration such as 20s, got %q", key, value)
+	}
+
+	*duration = parsedDuration
+	return nil
+}
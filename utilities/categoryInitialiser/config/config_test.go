@@ -0,0 +1,83 @@
+//go:build !integrationTest
+
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingProvider struct {
+	err error
+}
+
+func (provider failingProvider) Lookup(context.Context, string) (string, bool, error) {
+	return "", false, provider.err
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("given only connection string, when Load called, then defaults used for everything else", func(t *testing.T) {
+		config, err := Load(context.Background(), InMemoryProvider{CockroachDbConnectionStringKey: "postgresql://root@localhost:26257"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, Config{
+			CockroachDbConnectionString: "postgresql://root@localhost:26257",
+			CockroachDbPoolSize:         2,
+			ProfileLockLeaseDuration:    60 * time.Second,
+			ProfileLockWaitTimeout:      20 * time.Second,
+		}, config)
+	})
+
+	t.Run("given every value, when Load called, then typed config returned", func(t *testing.T) {
+		config, err := Load(context.Background(), InMemoryProvider{
+			CockroachDbConnectionStringKey: "postgresql://root@localhost:26257",
+			CockroachDbPoolSizeKey:         "4",
+			DefaultTemplateKey:             "minimal@1",
+			ProfileLockLeaseDurationKey:    "2m",
+			ProfileLockWaitTimeoutKey:      "500ms",
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, Config{
+			CockroachDbConnectionString: "postgresql://root@localhost:26257",
+			CockroachDbPoolSize:         4,
+			DefaultTemplate:             "minimal@1",
+			ProfileLockLeaseDuration:    2 * time.Minute,
+			ProfileLockWaitTimeout:      500 * time.Millisecond,
+		}, config)
+	})
+
+	t.Run("given no connection string, when Load called, then ErrMissingConnectionString returned", func(t *testing.T) {
+		_, err := Load(context.Background(), InMemoryProvider{CockroachDbPoolSizeKey: "4"})
+
+		assert.ErrorIs(t, err, ErrMissingConnectionString)
+	})
+
+	invalidValues := map[string]string{
+		CockroachDbPoolSizeKey:      "1",
+		ProfileLockLeaseDurationKey: "sixty seconds",
+		ProfileLockWaitTimeoutKey:   "-1s",
+	}
+	for key, value := range invalidValues {
+		t.Run("given invalid "+key+", when Load called, then error returned", func(t *testing.T) {
+			_, err := Load(context.Background(), InMemoryProvider{
+				CockroachDbConnectionStringKey: "postgresql://root@localhost:26257",
+				key:                            value,
+			})
+
+			assert.ErrorContains(t, err, key)
+		})
+	}
+
+	t.Run("given provider fails, when Load called, then error returned", func(t *testing.T) {
+		providerError := errors.New("access denied")
+
+		_, err := Load(context.Background(), failingProvider{err: providerError})
+
+		assert.ErrorIs(t, err, providerError)
+	})
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"context"
+	"os"
+)
+
+// DefaultEnvVariables maps each configuration key to the environment variable it is read from
+var DefaultEnvVariables = map[string]string{
+	CockroachDbConnectionStringKey: "CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING",
+	CockroachDbPoolSizeKey:         "CATEGORY_INITIALISER_COCKROACHDB_POOL_SIZE",
+	DefaultTemplateKey:             "CATEGORY_INITIALISER_DEFAULT_TEMPLATE",
+	ProfileLockLeaseDurationKey:    "CATEGORY_INITIALISER_PROFILE_LOCK_LEASE_DURATION",
+	ProfileLockWaitTimeoutKey:      "CATEGORY_INITIALISER_PROFILE_LOCK_WAIT_TIMEOUT",
+}
+
+// EnvProvider reads configuration from environment variables. Keys without an entry in Variables are never found.
+type EnvProvider struct {
+	Variables map[string]string
+}
+
+func (provider EnvProvider) Lookup(_ context.Context, key string) (string, bool, error) {
+	variable, ok := provider.Variables[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	value, found := os.LookupEnv(variable)
+	return value, found, nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileProvider reads configuration from a JSON object keyed by configuration key, e.g.
+// {"cockroachDbPoolSize": 4, "defaultTemplate": "au-default@1"}. The file is read on the first lookup.
+type FileProvider struct {
+	Path string
+
+	once   sync.Once
+	values map[string]string
+	err    error
+}
+
+func (provider *FileProvider) Lookup(_ context.Context, key string) (string, bool, error) {
+	provider.once.Do(provider.load)
+	if provider.err != nil {
+		return "", false, provider.err
+	}
+
+	value, found := provider.values[key]
+	return value, found, nil
+}
+
+func (provider *FileProvider) load() {
+	contents, err := os.ReadFile(provider.Path)
+	if err != nil {
+		provider.err = fmt.Errorf("failed to read config file: %w", err)
+		return
+	}
+
+	provider.values, err = parseJsonValues(contents)
+	if err != nil {
+		provider.err = fmt.Errorf("failed to parse config file %s: %w", provider.Path, err)
+	}
+}
+
+// parseJsonValues flattens a JSON object of strings, numbers and booleans into strings
+func parseJsonValues(contents []byte) (map[string]string, error) {
+	var rawValues map[string]any
+	if err := json.Unmarshal(contents, &rawValues); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(rawValues))
+	for key, rawValue := range rawValues {
+		switch rawValue.(type) {
+		case string, float64, bool:
+			values[key] = fmt.Sprint(rawValue)
+		default:
+			return nil, fmt.Errorf("value of %s must be a string, number or boolean", key)
+		}
+	}
+
+	return values, nil
+}
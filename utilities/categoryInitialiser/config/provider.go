@@ -0,0 +1,32 @@
+package config
+
+import "context"
+
+// Provider looks up a single configuration value by key. found is false when the provider has no value for the key,
+// err is only returned when the provider itself failed.
+type Provider interface {
+	Lookup(ctx context.Context, key string) (value string, found bool, err error)
+}
+
+// LayeredProvider looks up each key in its providers in order and returns the first value found, so earlier providers
+// take precedence over later ones
+type LayeredProvider []Provider
+
+func (providers LayeredProvider) Lookup(ctx context.Context, key string) (string, bool, error) {
+	for _, provider := range providers {
+		value, found, err := provider.Lookup(ctx, key)
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+
+	return "", false, nil
+}
+
+// InMemoryProvider serves values from a map, it is mostly useful in tests
+type InMemoryProvider map[string]string
+
+func (provider InMemoryProvider) Lookup(_ context.Context, key string) (string, bool, error) {
+	value, found := provider[key]
+	return value, found, nil
+}
@@ -0,0 +1,183 @@
+//go:build !integrationTest
+
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSsmClient struct {
+	parameters map[string]string
+	requested  []string
+}
+
+func (client *fakeSsmClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	client.requested = append(client.requested, *params.Name)
+
+	value, ok := client.parameters[*params.Name]
+	if !ok {
+		return nil, &types.ParameterNotFound{}
+	}
+
+	return &ssm.GetParameterOutput{Parameter: &types.Parameter{Value: aws.String(value)}}, nil
+}
+
+type fakeSecretsManagerClient struct {
+	secretString string
+	err          error
+	calls        int
+}
+
+func (client *fakeSecretsManagerClient) GetSecretValue(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	client.calls++
+	if client.err != nil {
+		return nil, client.err
+	}
+
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(client.secretString)}, nil
+}
+
+func TestLayeredProvider(t *testing.T) {
+	provider := LayeredProvider{
+		InMemoryProvider{DefaultTemplateKey: "minimal@1"},
+		InMemoryProvider{DefaultTemplateKey: "au-default@1", CockroachDbPoolSizeKey: "4"},
+	}
+
+	t.Run("given key in several providers, when Lookup called, then first provider wins", func(t *testing.T) {
+		value, found, err := provider.Lookup(context.Background(), DefaultTemplateKey)
+
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "minimal@1", value)
+	})
+
+	t.Run("given key only in later provider, when Lookup called, then later provider used", func(t *testing.T) {
+		value, found, _ := provider.Lookup(context.Background(), CockroachDbPoolSizeKey)
+
+		assert.True(t, found)
+		assert.Equal(t, "4", value)
+	})
+
+	t.Run("given key in no provider, when Lookup called, then not found", func(t *testing.T) {
+		_, found, err := provider.Lookup(context.Background(), ProfileLockWaitTimeoutKey)
+
+		assert.Nil(t, err)
+		assert.False(t, found)
+	})
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("CATEGORY_INITIALISER_COCKROACHDB_POOL_SIZE", "4")
+	provider := EnvProvider{Variables: DefaultEnvVariables}
+
+	value, found, err := provider.Lookup(context.Background(), CockroachDbPoolSizeKey)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "4", value)
+
+	_, found, _ = provider.Lookup(context.Background(), "unknownKey")
+	assert.False(t, found)
+}
+
+func TestFileProvider(t *testing.T) {
+	t.Run("given JSON config file, when Lookup called, then values returned as strings", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		os.WriteFile(path, []byte(`{"cockroachDbPoolSize": 4, "defaultTemplate": "minimal@1"}`), 0600)
+		provider := &FileProvider{Path: path}
+
+		poolSize, found, err := provider.Lookup(context.Background(), CockroachDbPoolSizeKey)
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "4", poolSize)
+
+		template, _, _ := provider.Lookup(context.Background(), DefaultTemplateKey)
+		assert.Equal(t, "minimal@1", template)
+	})
+
+	t.Run("given missing config file, when Lookup called, then error returned", func(t *testing.T) {
+		provider := &FileProvider{Path: filepath.Join(t.TempDir(), "missing.json")}
+
+		_, _, err := provider.Lookup(context.Background(), CockroachDbPoolSizeKey)
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestSsmProvider(t *testing.T) {
+	client := &fakeSsmClient{parameters: map[string]string{
+		"/test/categoryInitialiser/cockroachDbConnectionString": "postgresql://root@localhost:26257",
+	}}
+	provider := SsmProvider{Client: client, Parameters: SsmParameters("test")}
+
+	t.Run("given parameter exists, when Lookup called, then parameter value returned", func(t *testing.T) {
+		value, found, err := provider.Lookup(context.Background(), CockroachDbConnectionStringKey)
+
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "postgresql://root@localhost:26257", value)
+	})
+
+	t.Run("given key without parameter, when Lookup called, then SSM not called", func(t *testing.T) {
+		client.requested = nil
+
+		_, found, err := provider.Lookup(context.Background(), DefaultTemplateKey)
+
+		assert.Nil(t, err)
+		assert.False(t, found)
+		assert.Empty(t, client.requested)
+	})
+
+	t.Run("given cancelled context, when Lookup called, then context error returned", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, err := provider.Lookup(ctx, CockroachDbConnectionStringKey)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("given parameter does not exist, when Lookup called, then not found", func(t *testing.T) {
+		_, found, err := SsmProvider{Client: client, Parameters: SsmParameters("prod")}.Lookup(context.Background(), CockroachDbConnectionStringKey)
+
+		assert.Nil(t, err)
+		assert.False(t, found)
+	})
+}
+
+func TestSecretsManagerProvider(t *testing.T) {
+	t.Run("given JSON secret, when Lookup called several times, then secret fetched once", func(t *testing.T) {
+		client := &fakeSecretsManagerClient{secretString: `{"cockroachDbConnectionString": "postgresql://root@localhost:26257"}`}
+		provider := &SecretsManagerProvider{Client: client, SecretId: "categoryInitialiser"}
+
+		value, found, err := provider.Lookup(context.Background(), CockroachDbConnectionStringKey)
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "postgresql://root@localhost:26257", value)
+
+		_, found, _ = provider.Lookup(context.Background(), CockroachDbPoolSizeKey)
+		assert.False(t, found)
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("given Secrets Manager fails, when Lookup called, then error returned", func(t *testing.T) {
+		clientError := errors.New("access denied")
+		provider := &SecretsManagerProvider{Client: &fakeSecretsManagerClient{err: clientError}, SecretId: "categoryInitialiser"}
+
+		_, _, err := provider.Lookup(context.Background(), CockroachDbConnectionStringKey)
+
+		assert.ErrorIs(t, err, clientError)
+	})
+}
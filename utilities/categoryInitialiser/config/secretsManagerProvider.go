@@ -0,0 +1,50 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+type SecretsManagerClient interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// SecretsManagerProvider reads configuration from a Secrets Manager secret whose value is a JSON object keyed by
+// configuration key. The secret is fetched on the first lookup.
+type SecretsManagerProvider struct {
+	Client   SecretsManagerClient
+	SecretId string
+
+	once   sync.Once
+	values map[string]string
+	err    error
+}
+
+func (provider *SecretsManagerProvider) Lookup(ctx context.Context, key string) (string, bool, error) {
+	provider.once.Do(func() { provider.load(ctx) })
+	if provider.err != nil {
+		return "", false, provider.err
+	}
+
+	value, found := provider.values[key]
+	return value, found, nil
+}
+
+func (provider *SecretsManagerProvider) load(ctx context.Context) {
+	result, err := provider.Client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(provider.SecretId),
+	})
+	if err != nil {
+		provider.err = fmt.Errorf("failed to get secret %s: %w", provider.SecretId, err)
+		return
+	}
+
+	provider.values, err = parseJsonValues([]byte(aws.ToString(result.SecretString)))
+	if err != nil {
+		provider.err = fmt.Errorf("failed to parse secret %s: %w", provider.SecretId, err)
+	}
+}
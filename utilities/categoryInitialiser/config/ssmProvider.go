@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+type SsmClient interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// SsmParameters returns the SSM parameters the initialiser reads in the given environment
+func SsmParameters(environment string) map[string]string {
+	return map[string]string{
+		CockroachDbConnectionStringKey: fmt.Sprintf("/%s/categoryInitialiser/cockroachDbConnectionString", environment),
+	}
+}
+
+// SsmProvider reads configuration from SSM Parameter Store. Only keys listed in Parameters are looked up, so other
+// keys don't cost an API call.
+type SsmProvider struct {
+	Client     SsmClient
+	Parameters map[string]string
+}
+
+func (provider SsmProvider) Lookup(ctx context.Context, key string) (string, bool, error) {
+	parameterName, ok := provider.Parameters[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	result, err := provider.Client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(parameterName),
+		WithDecryption: aws.Bool(true),
+	})
+
+	var parameterNotFound *types.ParameterNotFound
+	if errors.As(err, &parameterNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get SSM parameter %s: %w", parameterName, err)
+	}
+
+	return aws.ToString(result.Parameter.Value), true, nil
+}
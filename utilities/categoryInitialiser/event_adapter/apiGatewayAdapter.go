@@ -0,0 +1,137 @@
+package event_adapter
+
+import (
+	"categoryInitialiser/category_provider"
+	"categoryInitialiser/models"
+	"categoryInitialiser/request_handler"
+	"categoryInitialiser/store"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+var (
+	ErrMissingCallerIdentity = errors.New("request has no caller identity")
+	ErrCallerMismatch        = errors.New("UserId does not match the caller")
+)
+
+// apiGatewayRequest holds the fields shared by the REST (v1) and HTTP (v2) API proxy payloads
+type apiGatewayRequest struct {
+	Body            string `json:"body"`
+	IsBase64Encoded bool   `json:"isBase64Encoded"`
+	RequestContext  struct {
+		Authorizer apiGatewayAuthorizer `json:"authorizer"`
+	} `json:"requestContext"`
+}
+
+// apiGatewayAuthorizer holds the JWT claims put in the request context by a REST API authorizer (claims) or an HTTP API
+// JWT authorizer (jwt.claims)
+type apiGatewayAuthorizer struct {
+	Claims apiGatewayClaims `json:"claims"`
+	Jwt    struct {
+		Claims apiGatewayClaims `json:"claims"`
+	} `json:"jwt"`
+}
+
+type apiGatewayClaims struct {
+	Subject string `json:"sub"`
+}
+
+// callerIdentity returns the Auth0 user identifier of the caller, the same claim MoneyMateApi identifies users by
+func (authorizer apiGatewayAuthorizer) callerIdentity() string {
+	if authorizer.Jwt.Claims.Subject != "" {
+		return authorizer.Jwt.Claims.Subject
+	}
+
+	return authorizer.Claims.Subject
+}
+
+type apiGatewayErrorBody struct {
+	Message string `json:"message"`
+}
+
+// routeApiGatewayRequest initialises the request in the body of an API Gateway proxy request. The user is the caller
+// identified by the authorizer, who has to belong to the profile, and a UserId in the body has to be theirs. Errors
+// are returned as HTTP responses rather than Lambda errors so that callers get a meaningful status code.
+func (router EventRouter) routeApiGatewayRequest(ctx context.Context, payload []byte) (events.APIGatewayProxyResponse, error) {
+	var proxyRequest apiGatewayRequest
+	if err := json.Unmarshal(payload, &proxyRequest); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	callerIdentity := proxyRequest.RequestContext.Authorizer.callerIdentity()
+	if callerIdentity == "" {
+		return newApiGatewayErrorResponse(ErrMissingCallerIdentity), nil
+	}
+
+	body := []byte(proxyRequest.Body)
+	if proxyRequest.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(proxyRequest.Body)
+		if err != nil {
+			return newApiGatewayErrorResponse(fmt.Errorf("%w: %s", ErrInvalidRequest, err)), nil
+		}
+		body = decoded
+	}
+
+	var request models.IntialiseCategoriesRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return newApiGatewayErrorResponse(fmt.Errorf("%w: %s", ErrInvalidRequest, err)), nil
+	}
+	if request.ProfileId == "" {
+		return newApiGatewayErrorResponse(fmt.Errorf("%w: ProfileId is required", ErrInvalidRequest)), nil
+	}
+
+	userId, err := router.AuthoriseCaller(ctx, callerIdentity, request.ProfileId)
+	if err != nil {
+		return newApiGatewayErrorResponse(err), nil
+	}
+	if request.UserId != "" && request.UserId != userId {
+		return newApiGatewayErrorResponse(ErrCallerMismatch), nil
+	}
+	request.UserId = userId
+
+	response, err := router.Initialise(ctx, request)
+	if err != nil {
+		return newApiGatewayErrorResponse(err), nil
+	}
+
+	return newApiGatewayResponse(http.StatusOK, response), nil
+}
+
+func newApiGatewayErrorResponse(err error) events.APIGatewayProxyResponse {
+	switch {
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, category_provider.ErrTemplateNotFound):
+		return newApiGatewayResponse(http.StatusBadRequest, apiGatewayErrorBody{Message: err.Error()})
+	case errors.Is(err, ErrMissingCallerIdentity):
+		return newApiGatewayResponse(http.StatusUnauthorized, apiGatewayErrorBody{Message: err.Error()})
+	case errors.Is(err, ErrCallerMismatch), errors.Is(err, store.ErrProfileNotAccessible), errors.Is(err, category_provider.ErrSourceProfileNotAccessible):
+		return newApiGatewayResponse(http.StatusForbidden, apiGatewayErrorBody{Message: err.Error()})
+	case errors.Is(err, request_handler.ErrInitialisationInProgress):
+		return newApiGatewayResponse(http.StatusConflict, apiGatewayErrorBody{Message: err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		return newApiGatewayResponse(http.StatusServiceUnavailable, apiGatewayErrorBody{Message: "timed out initialising categories, try again"})
+	default:
+		log.Printf("failed to process API Gateway request: %s", err)
+		return newApiGatewayResponse(http.StatusInternalServerError, apiGatewayErrorBody{Message: http.StatusText(http.StatusInternalServerError)})
+	}
+}
+
+func newApiGatewayResponse(statusCode int, body interface{}) events.APIGatewayProxyResponse {
+	responseBody, err := json.Marshal(body)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		responseBody = []byte(`{"message":"Internal Server Error"}`)
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(responseBody),
+	}
+}
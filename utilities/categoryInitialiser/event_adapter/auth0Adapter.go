@@ -0,0 +1,40 @@
+package event_adapter
+
+import (
+	"categoryInitialiser/models"
+	"encoding/json"
+	"strings"
+)
+
+// auth0DatabaseConnectionPrefix is prepended to Auth0 user ids to form the subject claim the API stores as user_identifier.
+// Post user registration hooks only run for database connections.
+const auth0DatabaseConnectionPrefix = "auth0|"
+
+// ParseAuth0User recognises the payload sent by the Auth0 post user registration hook
+func ParseAuth0User(payload []byte) (user models.Auth0User, ok bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return models.Auth0User{}, false
+	}
+
+	_, hasTenant := fields["tenant"]
+	_, hasEmail := fields["email"]
+	if !hasTenant && !hasEmail {
+		return models.Auth0User{}, false
+	}
+
+	if err := json.Unmarshal(payload, &user); err != nil || user.Id == "" {
+		return models.Auth0User{}, false
+	}
+
+	return user, true
+}
+
+// UserIdentifier returns the identifier the API uses for the Auth0 user
+func UserIdentifier(user models.Auth0User) string {
+	if strings.Contains(user.Id, "|") {
+		return user.Id
+	}
+
+	return auth0DatabaseConnectionPrefix + user.Id
+}
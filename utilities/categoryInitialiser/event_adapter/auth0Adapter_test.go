@@ -0,0 +1,45 @@
+//go:build !integrationTest
+
+package event_adapter
+
+import (
+	"categoryInitialiser/models"
+	_ "embed"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata/auth0PostUserRegistration.json
+var auth0PostUserRegistrationEvent []byte
+
+func TestParseAuth0User(t *testing.T) {
+	t.Run("given Auth0 post user registration payload, when ParseAuth0User called, then user returned", func(t *testing.T) {
+		user, ok := ParseAuth0User(auth0PostUserRegistrationEvent)
+
+		assert.True(t, ok)
+		assert.Equal(t, models.Auth0User{
+			Id:       "64b0f2a1c3e4d5f6a7b8c9d0",
+			Tenant:   "moneymate-dev",
+			Username: "golang_test",
+			Email:    "golang_test@moneymate.com",
+		}, user)
+	})
+
+	t.Run("given initialise categories request payload, when ParseAuth0User called, then payload not recognised", func(t *testing.T) {
+		_, ok := ParseAuth0User([]byte(`{"UserId": "c3a1a4d2-5a8b-4d6e-9f3a-2b1c0d9e8f7a", "ProfileId": "0f9e8d7c-6b5a-4c3d-2e1f-0a9b8c7d6e5f"}`))
+
+		assert.False(t, ok)
+	})
+
+	t.Run("given Auth0 payload without id, when ParseAuth0User called, then payload not recognised", func(t *testing.T) {
+		_, ok := ParseAuth0User([]byte(`{"tenant": "moneymate-dev", "email": "golang_test@moneymate.com"}`))
+
+		assert.False(t, ok)
+	})
+}
+
+func TestUserIdentifier(t *testing.T) {
+	assert.Equal(t, "auth0|64b0f2a1c3e4d5f6a7b8c9d0", UserIdentifier(models.Auth0User{Id: "64b0f2a1c3e4d5f6a7b8c9d0"}))
+	assert.Equal(t, "google-oauth2|1234", UserIdentifier(models.Auth0User{Id: "google-oauth2|1234"}))
+}
@@ -0,0 +1,41 @@
+package event_adapter
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ProfileCreatedDetailType is the detail-type of the event published when a profile is created. Its detail carries
+// the same fields as IntialiseCategoriesRequest.
+const ProfileCreatedDetailType = "ProfileCreated"
+
+func (router EventRouter) routeEventBridgeEvent(ctx context.Context, payload []byte) (models.InitialiseCategoriesResponse, error) {
+	request, err := parseEventBridgeEvent(payload)
+	if err != nil {
+		return models.InitialiseCategoriesResponse{}, err
+	}
+
+	return router.Initialise(ctx, request)
+}
+
+func parseEventBridgeEvent(payload []byte) (models.IntialiseCategoriesRequest, error) {
+	var event events.CloudWatchEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return models.IntialiseCategoriesRequest{}, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	if event.DetailType != ProfileCreatedDetailType {
+		return models.IntialiseCategoriesRequest{}, fmt.Errorf("%w: unsupported EventBridge detail-type %q", ErrInvalidRequest, event.DetailType)
+	}
+
+	var request models.IntialiseCategoriesRequest
+	if err := json.Unmarshal(event.Detail, &request); err != nil {
+		return models.IntialiseCategoriesRequest{}, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	return request, validateRequest(request)
+}
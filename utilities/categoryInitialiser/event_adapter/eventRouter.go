@@ -0,0 +1,99 @@
+package event_adapter
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidRequest = errors.New("invalid initialise categories request")
+
+// Initialiser initialises the categories for a single request
+type Initialiser func(ctx context.Context, request models.IntialiseCategoriesRequest) (models.InitialiseCategoriesResponse, error)
+
+// Auth0Initialiser creates the Auth0 user's default profile and initialises its categories
+type Auth0Initialiser func(ctx context.Context, user models.Auth0User) (models.InitialiseCategoriesResponse, error)
+
+// CallerAuthoriser returns the id of the user with userIdentifier, or store.ErrProfileNotAccessible unless they belong
+// to profileId
+type CallerAuthoriser func(ctx context.Context, userIdentifier string, profileId string) (userId string, err error)
+
+// EventRouter works out which AWS service invoked the Lambda and hands each initialise categories request it carries
+// to Initialise. The response is shaped for the invoking service.
+type EventRouter struct {
+	Initialise          Initialiser
+	InitialiseAuth0User Auth0Initialiser
+	// AuthoriseCaller is only used for API Gateway requests, the other sources are trusted AWS services
+	AuthoriseCaller CallerAuthoriser
+}
+
+// eventProbe holds just enough of each supported event shape to tell them apart
+type eventProbe struct {
+	Records []struct {
+		EventSource string `json:"eventSource"`
+	} `json:"Records"`
+	DetailType     string          `json:"detail-type"`
+	HttpMethod     string          `json:"httpMethod"`
+	RouteKey       string          `json:"routeKey"`
+	RequestContext json.RawMessage `json:"requestContext"`
+}
+
+func (router EventRouter) Route(ctx context.Context, payload []byte) (interface{}, error) {
+	var probe eventProbe
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(probe.Records) > 0 && probe.Records[0].EventSource == sqsEventSource:
+		return router.routeSqsEvent(ctx, payload)
+	case probe.RequestContext != nil && (probe.HttpMethod != "" || probe.RouteKey != ""):
+		return router.routeApiGatewayRequest(ctx, payload)
+	case probe.DetailType != "":
+		return router.routeEventBridgeEvent(ctx, payload)
+	}
+
+	if user, ok := ParseAuth0User(payload); ok {
+		return router.InitialiseAuth0User(ctx, user)
+	}
+
+	var request models.IntialiseCategoriesRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, err
+	}
+
+	return router.Initialise(ctx, request)
+}
+
+// parseRequest reads an initialise categories request from a message body, which is either the request itself or an
+// EventBridge event that was delivered through a queue
+func parseRequest(body []byte) (models.IntialiseCategoriesRequest, error) {
+	var probe eventProbe
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return models.IntialiseCategoriesRequest{}, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	if probe.DetailType != "" {
+		return parseEventBridgeEvent(body)
+	}
+
+	var request models.IntialiseCategoriesRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return models.IntialiseCategoriesRequest{}, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	return request, validateRequest(request)
+}
+
+func validateRequest(request models.IntialiseCategoriesRequest) error {
+	if request.UserId == "" {
+		return fmt.Errorf("%w: UserId is required", ErrInvalidRequest)
+	}
+	if request.ProfileId == "" {
+		return fmt.Errorf("%w: ProfileId is required", ErrInvalidRequest)
+	}
+
+	return nil
+}
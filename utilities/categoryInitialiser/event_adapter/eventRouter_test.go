@@ -0,0 +1,252 @@
+//go:build !integrationTest
+
+package event_adapter
+
+import (
+	"categoryInitialiser/models"
+	"categoryInitialiser/request_handler"
+	"categoryInitialiser/store"
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed testdata/sqsEvent.json
+var sqsEvent []byte
+
+//go:embed testdata/eventBridgeProfileCreated.json
+var eventBridgeProfileCreatedEvent []byte
+
+//go:embed testdata/apiGatewayProxyRequest.json
+var apiGatewayProxyRequest []byte
+
+const (
+	userId         = "c3a1a4d2-5a8b-4d6e-9f3a-2b1c0d9e8f7a"
+	profileId      = "0f9e8d7c-6b5a-4c3d-2e1f-0a9b8c7d6e5f"
+	userIdentifier = "auth0|64b0f2a1c3e4d5f6a7b8c9d0"
+)
+
+type recordingInitialiser struct {
+	requests []models.IntialiseCategoriesRequest
+	users    []models.Auth0User
+	err      error
+}
+
+func (initialiser *recordingInitialiser) router() EventRouter {
+	return EventRouter{
+		Initialise: func(_ context.Context, request models.IntialiseCategoriesRequest) (models.InitialiseCategoriesResponse, error) {
+			initialiser.requests = append(initialiser.requests, request)
+			return models.InitialiseCategoriesResponse{InsertedCategoryCount: 9}, initialiser.err
+		},
+		InitialiseAuth0User: func(_ context.Context, user models.Auth0User) (models.InitialiseCategoriesResponse, error) {
+			initialiser.users = append(initialiser.users, user)
+			return models.InitialiseCategoriesResponse{InsertedCategoryCount: 9}, initialiser.err
+		},
+		AuthoriseCaller: func(_ context.Context, caller string, callerProfileId string) (string, error) {
+			if caller != userIdentifier || callerProfileId != profileId {
+				return "", store.ErrProfileNotAccessible
+			}
+			return userId, nil
+		},
+	}
+}
+
+func TestEventRouter(t *testing.T) {
+	t.Run("given initialise categories request, when Route called, then request initialised", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), []byte(`{"UserId": "`+userId+`", "ProfileId": "`+profileId+`"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.InitialiseCategoriesResponse{InsertedCategoryCount: 9}, response)
+		assert.Equal(t, []models.IntialiseCategoriesRequest{{UserId: userId, ProfileId: profileId}}, initialiser.requests)
+	})
+
+	t.Run("given Auth0 post user registration payload, when Route called, then Auth0 user initialised", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		_, err := initialiser.router().Route(context.Background(), auth0PostUserRegistrationEvent)
+
+		assert.Nil(t, err)
+		assert.Len(t, initialiser.users, 1)
+		assert.Empty(t, initialiser.requests)
+	})
+
+	t.Run("given SQS event, when Route called, then each valid message initialised and invalid message reported as failure", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), sqsEvent)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []models.IntialiseCategoriesRequest{
+			{UserId: userId, ProfileId: profileId},
+			{UserId: userId, ProfileId: "9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d", Template: "minimal@1"},
+		}, initialiser.requests)
+		assert.Equal(t, events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{
+			{ItemIdentifier: "d9b3c2a1-8f7e-4d6c-b5a4-3e2f1d0c9b8a"},
+		}}, response)
+	})
+
+	t.Run("given SQS event and initialisation fails, when Route called, then every message reported as failure", func(t *testing.T) {
+		initialiser := &recordingInitialiser{err: request_handler.ErrInitialisationInProgress}
+
+		response, err := initialiser.router().Route(context.Background(), sqsEvent)
+
+		assert.Nil(t, err)
+		assert.Len(t, response.(events.SQSEventResponse).BatchItemFailures, 3)
+	})
+
+	t.Run("given SQS event and cancelled context, when Route called, then every message reported as failure without being initialised", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		response, err := initialiser.router().Route(ctx, sqsEvent)
+
+		assert.Nil(t, err)
+		assert.Len(t, response.(events.SQSEventResponse).BatchItemFailures, 3)
+		assert.Empty(t, initialiser.requests)
+	})
+
+	t.Run("given SQS event, when Route called, then response serialises to partial batch failure format", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, _ := initialiser.router().Route(context.Background(), sqsEvent)
+		responseJson, err := json.Marshal(response)
+
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"batchItemFailures": [{"itemIdentifier": "d9b3c2a1-8f7e-4d6c-b5a4-3e2f1d0c9b8a"}]}`, string(responseJson))
+	})
+
+	t.Run("given EventBridge ProfileCreated event, when Route called, then detail initialised", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), eventBridgeProfileCreatedEvent)
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.InitialiseCategoriesResponse{InsertedCategoryCount: 9}, response)
+		assert.Equal(t, []models.IntialiseCategoriesRequest{
+			{UserId: userId, ProfileId: profileId, Template: "minimal@1"},
+		}, initialiser.requests)
+	})
+
+	t.Run("given EventBridge event with unsupported detail-type, when Route called, then error returned", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		_, err := initialiser.router().Route(context.Background(), []byte(`{"detail-type": "ProfileDeleted", "source": "moneymate.api", "detail": {"UserId": "`+userId+`", "ProfileId": "`+profileId+`"}}`))
+
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+		assert.Empty(t, initialiser.requests)
+	})
+
+	t.Run("given API Gateway proxy request, when Route called, then body initialised and response returned with status 200", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), apiGatewayProxyRequest)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []models.IntialiseCategoriesRequest{{UserId: userId, ProfileId: profileId}}, initialiser.requests)
+
+		proxyResponse := response.(events.APIGatewayProxyResponse)
+		assert.Equal(t, http.StatusOK, proxyResponse.StatusCode)
+
+		var body models.InitialiseCategoriesResponse
+		assert.Nil(t, json.Unmarshal([]byte(proxyResponse.Body), &body))
+		assert.Equal(t, 9, body.InsertedCategoryCount)
+	})
+
+	t.Run("given API Gateway proxy request with base64 encoded body, when Route called, then body decoded", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+		body := base64.StdEncoding.EncodeToString([]byte(`{"UserId": "` + userId + `", "ProfileId": "` + profileId + `"}`))
+
+		response, err := initialiser.router().Route(context.Background(), []byte(`{"routeKey": "POST /categories/initialise", "requestContext": {"authorizer": {"jwt": {"claims": {"sub": "`+userIdentifier+`"}}}}, "isBase64Encoded": true, "body": "`+body+`"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.(events.APIGatewayProxyResponse).StatusCode)
+		assert.Equal(t, []models.IntialiseCategoriesRequest{{UserId: userId, ProfileId: profileId}}, initialiser.requests)
+	})
+
+	t.Run("given API Gateway proxy request without ProfileId, when Route called, then status 400 returned", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), apiGatewayRequestWithBody(`{"UserId": "`+userId+`"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.(events.APIGatewayProxyResponse).StatusCode)
+		assert.Empty(t, initialiser.requests)
+	})
+
+	t.Run("given API Gateway proxy request without UserId, when Route called, then caller's user initialised", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), apiGatewayRequestWithBody(`{"ProfileId": "`+profileId+`"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.(events.APIGatewayProxyResponse).StatusCode)
+		assert.Equal(t, []models.IntialiseCategoriesRequest{{UserId: userId, ProfileId: profileId}}, initialiser.requests)
+	})
+
+	t.Run("given API Gateway proxy request without caller identity, when Route called, then status 401 returned", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), []byte(`{"httpMethod": "POST", "requestContext": {}, "body": "{\"UserId\": \"`+userId+`\", \"ProfileId\": \"`+profileId+`\"}"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, response.(events.APIGatewayProxyResponse).StatusCode)
+		assert.Empty(t, initialiser.requests)
+	})
+
+	t.Run("given API Gateway proxy request naming another user, when Route called, then status 403 returned", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), apiGatewayRequestWithBody(`{"UserId": "another-user", "ProfileId": "`+profileId+`"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusForbidden, response.(events.APIGatewayProxyResponse).StatusCode)
+		assert.Empty(t, initialiser.requests)
+	})
+
+	t.Run("given API Gateway proxy request for a profile the caller doesn't belong to, when Route called, then status 403 returned", func(t *testing.T) {
+		initialiser := &recordingInitialiser{}
+
+		response, err := initialiser.router().Route(context.Background(), apiGatewayRequestWithBody(`{"ProfileId": "another-profile"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusForbidden, response.(events.APIGatewayProxyResponse).StatusCode)
+		assert.Empty(t, initialiser.requests)
+	})
+
+	errorStatusCodes := map[error]int{
+		request_handler.ErrInitialisationInProgress: http.StatusConflict,
+		context.DeadlineExceeded:                    http.StatusServiceUnavailable,
+		errors.New("connection refused"):            http.StatusInternalServerError,
+	}
+	for initialiseError, statusCode := range errorStatusCodes {
+		t.Run("given API Gateway proxy request and initialisation fails with "+initialiseError.Error()+", when Route called, then error status returned", func(t *testing.T) {
+			initialiser := &recordingInitialiser{err: initialiseError}
+
+			response, err := initialiser.router().Route(context.Background(), apiGatewayProxyRequest)
+
+			assert.Nil(t, err)
+			assert.Equal(t, statusCode, response.(events.APIGatewayProxyResponse).StatusCode)
+		})
+	}
+}
+
+// apiGatewayRequestWithBody returns an HTTP API proxy request from the caller identified by userIdentifier
+func apiGatewayRequestWithBody(body string) []byte {
+	proxyRequest, _ := json.Marshal(map[string]interface{}{
+		"routeKey":       "POST /categories/initialise",
+		"requestContext": map[string]interface{}{"authorizer": map[string]interface{}{"jwt": map[string]interface{}{"claims": map[string]string{"sub": userIdentifier}}}},
+		"body":           body,
+	})
+
+	return proxyRequest
+}
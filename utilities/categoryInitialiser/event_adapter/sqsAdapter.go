@@ -0,0 +1,47 @@
+package event_adapter
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const sqsEventSource = "aws:sqs"
+
+// routeSqsEvent initialises each message in the batch on its own and reports the messages that failed, so that only
+// those are returned to the queue. The event source mapping must have ReportBatchItemFailures enabled. Once ctx is done
+// the remaining messages are reported as failures without being attempted.
+func (router EventRouter) routeSqsEvent(ctx context.Context, payload []byte) (events.SQSEventResponse, error) {
+	var event events.SQSEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return events.SQSEventResponse{}, err
+	}
+
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+	for _, message := range event.Records {
+		if err := router.initialiseSqsMessage(ctx, message); err != nil {
+			log.Printf("failed to process SQS message %s: %s", message.MessageId, err)
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: message.MessageId,
+			})
+		}
+	}
+
+	return response, nil
+}
+
+func (router EventRouter) initialiseSqsMessage(ctx context.Context, message events.SQSMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	request, err := parseRequest([]byte(message.Body))
+	if err != nil {
+		return err
+	}
+
+	_, err = router.Initialise(ctx, request)
+	return err
+}
@@ -107,3 +107,30 @@ resource "aws_iam_policy_attachment" "category_initialiser_lambda_dynamodb" {
   aws_iam_role.category_initialiser_lambda.name]
   policy_arn = aws_iam_policy.category_initialiser_lambda_dynamodb.arn
 }
+
+# SQS
+data "aws_iam_policy_document" "sqs_access" {
+  statement {
+    effect = "Allow"
+    actions = [
+      "sqs:ReceiveMessage",
+      "sqs:DeleteMessage",
+      "sqs:GetQueueAttributes"
+    ]
+    resources = [
+    aws_sqs_queue.category_initialiser_queue.arn]
+  }
+}
+
+resource "aws_iam_policy" "category_initialiser_lambda_sqs" {
+  name   = "${local.category_initialiser_lambda_name}_sqs_acesss_policy_${terraform.workspace}"
+  policy = data.aws_iam_policy_document.sqs_access.json
+}
+
+resource "aws_iam_policy_attachment" "category_initialiser_lambda_sqs" {
+
+  name = "${local.category_initialiser_lambda_name}_sqs_access_attachment_${terraform.workspace}"
+  roles = [
+  aws_iam_role.category_initialiser_lambda.name]
+  policy_arn = aws_iam_policy.category_initialiser_lambda_sqs.arn
+}
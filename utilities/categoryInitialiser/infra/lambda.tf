@@ -14,6 +14,7 @@ resource "aws_lambda_function" category_initialiser_lambda {
     environment {
         variables = {
         ENVIRONMENT = terraform.workspace
+        CATEGORY_INITIALISER_COCKROACHDB_POOL_SIZE = var.CATEGORY_INITIALISER_COCKROACHDB_POOL_SIZE
         }
     }
     
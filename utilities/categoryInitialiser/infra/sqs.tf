@@ -0,0 +1,22 @@
+resource "aws_sqs_queue" "category_initialiser_dead_letter_queue" {
+  name = "category_initialiser_dead_letter_queue_${terraform.workspace}"
+  tags = local.tags
+}
+
+resource "aws_sqs_queue" "category_initialiser_queue" {
+  name = "category_initialiser_queue_${terraform.workspace}"
+  # Must be at least the Lambda timeout so messages aren't redelivered while they are still being processed
+  visibility_timeout_seconds = 180
+  redrive_policy = jsonencode({
+    deadLetterTargetArn = aws_sqs_queue.category_initialiser_dead_letter_queue.arn
+    maxReceiveCount     = 5
+  })
+  tags = local.tags
+}
+
+resource "aws_lambda_event_source_mapping" "category_initialiser_queue" {
+  event_source_arn        = aws_sqs_queue.category_initialiser_queue.arn
+  function_name           = aws_lambda_function.category_initialiser_lambda.arn
+  batch_size              = 10
+  function_response_types = ["ReportBatchItemFailures"]
+}
@@ -3,6 +3,11 @@ variable CATEGORY_INITIALISER_LAMBDA_IMAGE_TAG {
   default = "latest"
 }
 
+variable CATEGORY_INITIALISER_COCKROACHDB_POOL_SIZE {
+  description = "Maximum number of CockroachDB connections each category_initialiser_lambda container keeps open"
+  default = 2
+}
+
 variable "auth0_management_api_client_id" {
   description = "The Client ID of the Auth0 management API"
   type = string
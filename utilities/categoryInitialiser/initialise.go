@@ -0,0 +1,164 @@
+package main
+
+import (
+	"categoryInitialiser/config"
+	"categoryInitialiser/models"
+	"categoryInitialiser/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	textFormat = "text"
+	jsonFormat = "json"
+)
+
+// runInitialise initialises the categories of a single profile, or with -dry-run prints what initialising it would
+// write without writing anything
+func runInitialise(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("initialise", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var request models.IntialiseCategoriesRequest
+	flags.StringVar(&request.UserId, "user-id", "", "id of the user that owns the profile")
+	flags.StringVar(&request.ProfileId, "profile-id", "", "id of the profile to initialise")
+	flags.StringVar(&request.Template, "template", "", "template pack to initialise from, e.g. au-default@1")
+	flags.StringVar(&request.SourceProfileId, "source-profile-id", "", "profile to copy categories from instead of a template pack")
+	flags.BoolVar(&request.DryRun, "dry-run", false, "print what would be written without writing it")
+	format := flags.String("format", textFormat, "output format, text or json")
+	databaseUrl := flags.String("database-url", "", "CockroachDB connection string, overrides the configured connection string")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if request.UserId == "" || request.ProfileId == "" {
+		fmt.Fprintln(stderr, "-user-id and -profile-id are required")
+		return 2
+	}
+	if *format != textFormat && *format != jsonFormat {
+		fmt.Fprintf(stderr, "unknown format %q, expected %s or %s\n", *format, textFormat, jsonFormat)
+		return 2
+	}
+
+	ctx := context.Background()
+
+	cfg, err := loadCommandConfig(ctx, *databaseUrl)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	// the profile lock is taken on a second connection of the pool, outside of the initialisation's transaction
+	pool, err := store.NewCockroachDbPool(cfg.CockroachDbConnectionString, cfg.CockroachDbPoolSize)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer pool.Close()
+
+	response, err := initialiseCategories(ctx, cfg, pool, request)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to initialise categories: %v\n", err)
+		return 1
+	}
+
+	if *format == jsonFormat {
+		err = writeJson(stdout, response)
+	} else if response.Plan != nil {
+		writePlan(stdout, *response.Plan)
+	} else {
+		writeResponse(stdout, response)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to write output: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// loadCommandConfig loads the configuration the same way as the Lambda when ENVIRONMENT is set and otherwise from
+// environment variables alone. A non-empty databaseUrl takes precedence over the configured connection string.
+func loadCommandConfig(ctx context.Context, databaseUrl string) (config.Config, error) {
+	providers := config.LayeredProvider{}
+	if databaseUrl != "" {
+		providers = append(providers, config.InMemoryProvider{config.CockroachDbConnectionStringKey: databaseUrl})
+	}
+
+	if _, ok := os.LookupEnv("ENVIRONMENT"); ok {
+		configProvider, err := newConfigProvider(ctx)
+		if err != nil {
+			return config.Config{}, err
+		}
+		providers = append(providers, configProvider)
+	} else {
+		providers = append(providers, config.EnvProvider{Variables: config.DefaultEnvVariables})
+	}
+
+	cfg, err := config.Load(ctx, providers)
+	if errors.Is(err, config.ErrMissingConnectionString) {
+		return config.Config{}, fmt.Errorf("%w, pass -database-url", err)
+	}
+
+	return cfg, err
+}
+
+func writeJson(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writePlan prints a line per category, subcategory, tag, payer or payee that would be created (+), skipped (=) or
+// conflicts with an existing one (!), followed by a summary
+func writePlan(w io.Writer, plan models.InitialisationPlan) {
+	fmt.Fprintf(w, "plan for profile %s from template %s\n", plan.ProfileId, plan.TemplateVersion)
+
+	for _, category := range plan.Create {
+		if !category.Exists {
+			fmt.Fprintf(w, "+ %s > %s\n", category.TransactionType, category.Name)
+		}
+		for _, subcategory := range category.Subcategories {
+			fmt.Fprintf(w, "+ %s > %s > %s\n", category.TransactionType, category.Name, subcategory)
+		}
+	}
+
+	for _, category := range plan.Skip {
+		fmt.Fprintf(w, "= %s > %s\n", category.TransactionType, category.Name)
+		for _, subcategory := range category.Subcategories {
+			fmt.Fprintf(w, "= %s > %s > %s\n", category.TransactionType, category.Name, subcategory)
+		}
+	}
+
+	for _, tag := range plan.TagsToCreate {
+		fmt.Fprintf(w, "+ tag %s\n", tag)
+	}
+	for _, tag := range plan.TagsToSkip {
+		fmt.Fprintf(w, "= tag %s\n", tag)
+	}
+
+	for _, payerPayee := range plan.PayerPayeesToCreate {
+		fmt.Fprintf(w, "+ %s %s\n", payerPayee.Type, payerPayee.Name)
+	}
+	for _, payerPayee := range plan.PayerPayeesToSkip {
+		fmt.Fprintf(w, "= %s %s\n", payerPayee.Type, payerPayee.Name)
+	}
+
+	for _, conflict := range plan.Conflicts {
+		fmt.Fprintf(w, "! %s\n", conflict)
+	}
+
+	fmt.Fprintf(w, "%d categories, %d subcategories, %d tags and %d payers and payees to create, %d categories, %d subcategories, %d tags and %d payers and payees to skip, %d conflicts\n",
+		plan.CategoriesToCreate, plan.SubcategoriesToCreate, len(plan.TagsToCreate), len(plan.PayerPayeesToCreate),
+		plan.CategoriesToSkip, plan.SubcategoriesToSkip, len(plan.TagsToSkip), len(plan.PayerPayeesToSkip), len(plan.Conflicts))
+}
+
+func writeResponse(w io.Writer, response models.InitialiseCategoriesResponse) {
+	fmt.Fprintf(w, "initialised categories from template %s: inserted %d categories, %d subcategories, %d tags and %d payers and payees, skipped %d existing categories, %d existing subcategories, %d existing tags and %d existing payers and payees\n",
+		response.TemplateVersion, response.InsertedCategoryCount, response.InsertedSubcategoryCount, response.InsertedTagCount, response.InsertedPayerPayeeCount,
+		response.SkippedCategoryCount, response.SkippedSubcategoryCount, response.SkippedTagCount, response.SkippedPayerPayeeCount)
+}
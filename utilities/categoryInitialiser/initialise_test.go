@@ -0,0 +1,74 @@
+//go:build !integrationTest
+
+package main
+
+import (
+	"bytes"
+	"categoryInitialiser/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInitialise(t *testing.T) {
+	t.Run("given no profile id, when initialise run, then usage error returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runInitialise([]string{"-user-id", "user"}, &stdout, &stderr)
+
+		assert.Equal(t, 2, exitCode)
+		assert.Contains(t, stderr.String(), "-profile-id")
+	})
+
+	t.Run("given unknown format, when initialise run, then usage error returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runInitialise([]string{"-user-id", "user", "-profile-id", "profile", "-format", "yaml"}, &stdout, &stderr)
+
+		assert.Equal(t, 2, exitCode)
+		assert.Contains(t, stderr.String(), `unknown format "yaml"`)
+	})
+}
+
+func TestWritePlan(t *testing.T) {
+	t.Run("given plan, when writePlan called, then creates, skips, tags, payers and payees, conflicts and summary written", func(t *testing.T) {
+		var output bytes.Buffer
+
+		writePlan(&output, models.InitialisationPlan{
+			ProfileId:       "profile",
+			TemplateVersion: "au-default@1",
+			Create: []models.PlannedCategory{
+				{TransactionType: "expense", Name: "Food", Exists: true, Subcategories: []string{"Coffee"}},
+				{TransactionType: "income", Name: "Salary", Exists: false, Subcategories: []string{"Bonus"}},
+			},
+			Skip: []models.PlannedCategory{
+				{TransactionType: "expense", Name: "Food", Exists: true, Subcategories: []string{"Groceries"}},
+			},
+			Conflicts: []models.PlanConflict{
+				{TransactionType: "income", Category: "Salary", Message: `category "Salary" already exists under transaction type expense`},
+			},
+			CategoriesToCreate:    1,
+			SubcategoriesToCreate: 2,
+			CategoriesToSkip:      1,
+			SubcategoriesToSkip:   1,
+			TagsToCreate:          []string{"Holiday"},
+			TagsToSkip:            []string{"Business"},
+			PayerPayeesToCreate:   []models.PayerPayeeDto{{Name: "Employer", Type: "payer"}},
+			PayerPayeesToSkip:     []models.PayerPayeeDto{{Name: "Coles", Type: "payee"}},
+		})
+
+		assert.Equal(t, `plan for profile profile from template au-default@1
++ expense > Food > Coffee
++ income > Salary
++ income > Salary > Bonus
+= expense > Food
+= expense > Food > Groceries
++ tag Holiday
+= tag Business
++ payer Employer
+= payee Coles
+! income > Salary: category "Salary" already exists under transaction type expense
+1 categories, 2 subcategories, 1 tags and 1 payers and payees to create, 1 categories, 1 subcategories, 1 tags and 1 payers and payees to skip, 1 conflicts
+`, output.String())
+	})
+}
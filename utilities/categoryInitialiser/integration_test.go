@@ -3,11 +3,16 @@
 package main
 
 import (
+	"bytes"
 	"categoryInitialiser/models"
+	"categoryInitialiser/request_handler"
 	"categoryInitialiser/test_utils"
 	"context"
+	"encoding/json"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
@@ -31,12 +36,14 @@ func TestCategoryInitialiser(t *testing.T) {
 
 		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
 
-		err := Handle(models.IntialiseCategoriesRequest{
+		response, err := Handle(context.Background(), models.IntialiseCategoriesRequest{
 			UserId:    userId,
 			ProfileId: profileId,
 		})
 
 		assert.Nil(t, err)
+		assert.Equal(t, 9, response.InsertedCategoryCount)
+		assert.Equal(t, 0, response.SkippedCategoryCount)
 
 		var numberOfCategories int
 		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category`).Scan(&numberOfCategories)
@@ -46,4 +53,456 @@ func TestCategoryInitialiser(t *testing.T) {
 
 		assert.Equal(t, 9, numberOfCategories)
 	})
+
+	t.Run("given warm Lambda container, when Lambda invoked twice, then connection pool reused", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		firstProfileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+		secondProfileId, _ := cockroachDbHelpers.CreateProfile("Second Profile")
+
+		_, err := Handle(context.Background(), models.IntialiseCategoriesRequest{UserId: userId, ProfileId: firstProfileId})
+		assert.Nil(t, err)
+		_, firstPool, _ := getDependencies(context.Background())
+
+		_, err = Handle(context.Background(), models.IntialiseCategoriesRequest{UserId: userId, ProfileId: secondProfileId})
+		assert.Nil(t, err)
+		_, secondPool, _ := getDependencies(context.Background())
+
+		assert.Same(t, firstPool, secondPool)
+		assert.LessOrEqual(t, secondPool.Stat().TotalConns(), secondPool.Config().MaxConns)
+	})
+
+	t.Run("given categories already initialised, when Lambda invoked again, then no error and no duplicate categories persisted", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		request := models.IntialiseCategoriesRequest{
+			UserId:    userId,
+			ProfileId: profileId,
+		}
+
+		_, err := Handle(context.Background(), request)
+		assert.Nil(t, err)
+
+		var numberOfSubcategories int
+		conn.QueryRow(context.Background(), "SELECT COUNT(1) from subcategory").Scan(&numberOfSubcategories)
+
+		response, err := Handle(context.Background(), request)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, response.InsertedCategoryCount)
+		assert.Equal(t, 9, response.SkippedCategoryCount)
+
+		var numberOfCategoriesAfterRetry int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category`).Scan(&numberOfCategoriesAfterRetry)
+
+		var numberOfSubcategoriesAfterRetry int
+		conn.QueryRow(context.Background(), "SELECT COUNT(1) from subcategory").Scan(&numberOfSubcategoriesAfterRetry)
+
+		assert.Equal(t, 9, numberOfCategoriesAfterRetry)
+		assert.Equal(t, numberOfSubcategories, numberOfSubcategoriesAfterRetry)
+	})
+
+	t.Run("given two concurrent invocations for the same profile, when Lambda invoked, then both succeed and categories persisted once", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		request := models.IntialiseCategoriesRequest{
+			UserId:    userId,
+			ProfileId: profileId,
+		}
+
+		var wg sync.WaitGroup
+		responses := make([]models.InitialiseCategoriesResponse, 2)
+		errs := make([]error, 2)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				responses[i], errs[i] = Handle(context.Background(), request)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Nil(t, errs[0])
+		assert.Nil(t, errs[1])
+
+		// the lock is only released once the first initialisation has committed, so the second one finds every row
+		// already there and skips it rather than racing the first one's inserts
+		first, second := responses[0], responses[1]
+		if first.InsertedCategoryCount == 0 {
+			first, second = second, first
+		}
+		assert.Equal(t, 9, first.InsertedCategoryCount)
+		assert.Equal(t, 58, first.InsertedSubcategoryCount)
+		assert.Equal(t, 0, second.InsertedCategoryCount)
+		assert.Equal(t, 9, second.SkippedCategoryCount)
+		assert.Equal(t, 0, second.InsertedSubcategoryCount)
+		assert.Equal(t, 58, second.SkippedSubcategoryCount)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category`).Scan(&numberOfCategories)
+
+		var numberOfSubcategories int
+		conn.QueryRow(context.Background(), "SELECT COUNT(1) from subcategory").Scan(&numberOfSubcategories)
+
+		var numberOfLocks int
+		conn.QueryRow(context.Background(), "SELECT COUNT(1) from profileinitialisationlock").Scan(&numberOfLocks)
+
+		assert.Equal(t, 9, numberOfCategories)
+		assert.Equal(t, 58, numberOfSubcategories)
+		assert.Equal(t, 0, numberOfLocks)
+	})
+
+	t.Run("given template in input payload, when Lambda invoked, then categories from that template persisted", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		response, err := Handle(context.Background(), models.IntialiseCategoriesRequest{
+			UserId:    userId,
+			ProfileId: profileId,
+			Template:  "minimal@1",
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "minimal@1", response.TemplateVersion)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category`).Scan(&numberOfCategories)
+		assert.Equal(t, 6, numberOfCategories)
+
+		var templateName string
+		conn.QueryRow(context.Background(), `SELECT template_name from profilecategorytemplate WHERE profile_id = $1`, profileId).Scan(&templateName)
+		assert.Equal(t, "minimal", templateName)
+	})
+
+	t.Run("given source profile in input payload, when Lambda invoked, then source profile categories copied to new profile", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		sourceProfileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+		cockroachDbHelpers.CreateUserProfile(userId, sourceProfileId)
+
+		newProfileId, _ := cockroachDbHelpers.CreateProfile("Household")
+		cockroachDbHelpers.CreateUserProfile(userId, newProfileId)
+
+		_, err := Handle(context.Background(), models.IntialiseCategoriesRequest{
+			UserId:    userId,
+			ProfileId: sourceProfileId,
+			Template:  "minimal",
+		})
+		assert.Nil(t, err)
+
+		response, err := Handle(context.Background(), models.IntialiseCategoriesRequest{
+			UserId:          userId,
+			ProfileId:       newProfileId,
+			SourceProfileId: sourceProfileId,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "minimal@1", response.TemplateVersion)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category WHERE profile_id = $1`, newProfileId).Scan(&numberOfCategories)
+		assert.Equal(t, 6, numberOfCategories)
+	})
+
+	t.Run("given Auth0 post user registration payload, when Lambda invoked, then user, default profile and categories persisted", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		payload, _ := os.ReadFile("event_adapter/testdata/auth0PostUserRegistration.json")
+
+		response, err := HandleEvent(context.Background(), payload)
+		assert.Nil(t, err)
+		assert.Equal(t, 9, response.(models.InitialiseCategoriesResponse).InsertedCategoryCount)
+
+		_, err = HandleEvent(context.Background(), payload)
+		assert.Nil(t, err)
+
+		var profileId string
+		conn.QueryRow(context.Background(),
+			`SELECT up.profile_id FROM userprofile up JOIN users u ON u.id = up.user_id WHERE u.user_identifier = $1`, "auth0|64b0f2a1c3e4d5f6a7b8c9d0",
+		).Scan(&profileId)
+		assert.NotEmpty(t, profileId)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category WHERE profile_id = $1`, profileId).Scan(&numberOfCategories)
+		assert.Equal(t, 9, numberOfCategories)
+
+		var numberOfProfiles int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from profile`).Scan(&numberOfProfiles)
+		assert.Equal(t, 1, numberOfProfiles)
+	})
+	t.Run("given Auth0 user that doesn't exist yet, when HandleAuth0Registration called, then profile lock taken and released without waiting on the new profile", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		// the lock's foreign key to the profile would block on an uncommitted profile until the context expires
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		response, err := HandleAuth0Registration(ctx, models.Auth0User{Id: "auth0|brand-new-user", Tenant: "moneymate-dev"})
+		assert.Nil(t, err)
+		assert.Equal(t, 9, response.InsertedCategoryCount)
+
+		var profileId string
+		conn.QueryRow(context.Background(),
+			`SELECT up.profile_id FROM userprofile up JOIN users u ON u.id = up.user_id WHERE u.user_identifier = $1`, "auth0|brand-new-user",
+		).Scan(&profileId)
+		assert.NotEmpty(t, profileId)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category WHERE profile_id = $1`, profileId).Scan(&numberOfCategories)
+		assert.Equal(t, 9, numberOfCategories)
+
+		var numberOfLocks int
+		conn.QueryRow(context.Background(), "SELECT COUNT(1) from profileinitialisationlock").Scan(&numberOfLocks)
+		assert.Equal(t, 0, numberOfLocks)
+	})
+
+	t.Run("given Lambda deadline within safety margin, when Lambda invoked, then deadline exceeded and no categories persisted", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		ctx, cancel := context.WithTimeout(context.Background(), request_handler.DeadlineSafetyMargin/2)
+		defer cancel()
+
+		payload, _ := json.Marshal(models.IntialiseCategoriesRequest{UserId: userId, ProfileId: profileId})
+		_, err := HandleEvent(ctx, payload)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category`).Scan(&numberOfCategories)
+		assert.Equal(t, 0, numberOfCategories)
+
+		var numberOfLocks int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from profileinitialisationlock`).Scan(&numberOfLocks)
+		assert.Equal(t, 0, numberOfLocks)
+	})
+
+	t.Run("given dry run in input payload, when Lambda invoked, then plan returned and nothing persisted", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		response, err := Handle(context.Background(), models.IntialiseCategoriesRequest{
+			UserId:    userId,
+			ProfileId: profileId,
+			DryRun:    true,
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, profileId, response.Plan.ProfileId)
+		assert.Equal(t, 9, response.Plan.CategoriesToCreate)
+		assert.Equal(t, 0, response.Plan.CategoriesToSkip)
+		assert.Empty(t, response.Plan.Conflicts)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category`).Scan(&numberOfCategories)
+		assert.Equal(t, 0, numberOfCategories)
+
+		var numberOfTemplates int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from profilecategorytemplate`).Scan(&numberOfTemplates)
+		assert.Equal(t, 0, numberOfTemplates)
+	})
+
+	t.Run("given profiles without categories, when backfill run, then each profile initialised and reported", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+		firstProfileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+		secondProfileId, _ := cockroachDbHelpers.CreateProfile("Second Profile")
+		cockroachDbHelpers.CreateUserProfile(userId, firstProfileId)
+		cockroachDbHelpers.CreateUserProfile(userId, secondProfileId)
+
+		var stdout, stderr bytes.Buffer
+		exitCode := runBackfill([]string{"-concurrency", "2"}, &stdout, &stderr)
+		assert.Equal(t, 0, exitCode)
+
+		var report models.BackfillReport
+		assert.Nil(t, json.Unmarshal(stdout.Bytes(), &report))
+		assert.Equal(t, 2, report.ProfilesFound)
+		assert.Len(t, report.Succeeded, 2)
+		assert.Empty(t, report.Failed)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) from category`).Scan(&numberOfCategories)
+		assert.Equal(t, 18, numberOfCategories)
+	})
+
+	t.Run("given template packs with and without tags, when Lambda invoked, then tags seeded only from the pack with tags", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+		defaultProfileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+		minimalProfileId, _ := cockroachDbHelpers.CreateProfile("Minimal Profile")
+
+		response, err := Handle(context.Background(), models.IntialiseCategoriesRequest{UserId: userId, ProfileId: defaultProfileId, Template: "au-default@2"})
+		assert.Nil(t, err)
+		assert.Equal(t, 4, response.InsertedTagCount)
+
+		response, err = Handle(context.Background(), models.IntialiseCategoriesRequest{UserId: userId, ProfileId: minimalProfileId, Template: "minimal@1"})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, response.InsertedTagCount)
+
+		var numberOfTags int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM tag WHERE profile_id = $1`, defaultProfileId).Scan(&numberOfTags)
+		assert.Equal(t, 4, numberOfTags)
+
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM tag WHERE profile_id = $1`, minimalProfileId).Scan(&numberOfTags)
+		assert.Equal(t, 0, numberOfTags)
+
+		response, err = Handle(context.Background(), models.IntialiseCategoriesRequest{UserId: userId, ProfileId: defaultProfileId, Template: "au-default@2"})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, response.InsertedTagCount)
+		assert.Equal(t, 4, response.SkippedTagCount)
+	})
+
+	t.Run("given template pack with payers and payees, when Lambda invoked, then payers and payees seeded once", func(t *testing.T) {
+		connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		os.Setenv("ENVIRONMENT", "dev")
+		os.Setenv("CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING", connectionString)
+
+		conn, _ := pgx.Connect(context.Background(), connectionString)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		response, err := Handle(context.Background(), models.IntialiseCategoriesRequest{UserId: userId, ProfileId: profileId, Template: "au-default@2"})
+		assert.Nil(t, err)
+		assert.Equal(t, 4, response.InsertedPayerPayeeCount)
+
+		rows, _ := conn.Query(context.Background(),
+			`SELECT pp.name || ':' || ppt.name
+			FROM payerpayee pp
+			JOIN payerpayeetype ppt ON ppt.id = pp.payerpayeetype_id
+			JOIN payerpayeeexternallinktype ppelt ON ppelt.id = pp.external_link_type_id
+			WHERE pp.profile_id = $1 AND pp.user_id = $2 AND ppelt.name = 'Custom' AND pp.external_link_id = ''
+			ORDER BY pp.name`, profileId, userId)
+		payerPayees, _ := pgx.CollectRows(rows, pgx.RowTo[string])
+		assert.Equal(t, []string{"Aldi:payee", "Coles:payee", "Employer:payer", "Woolworths:payee"}, payerPayees)
+
+		response, err = Handle(context.Background(), models.IntialiseCategoriesRequest{UserId: userId, ProfileId: profileId, Template: "au-default@2"})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, response.InsertedPayerPayeeCount)
+		assert.Equal(t, 4, response.SkippedPayerPayeeCount)
+	})
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"categoryInitialiser/category_provider"
+	"categoryInitialiser/store"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// runLint validates every template pack and returns a non-zero exit code if any pack has issues
+func runLint(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	packsDirectory := flags.String("dir", "", "directory of template packs to lint instead of the embedded packs")
+	databaseUrl := flags.String("database-url", "", "CockroachDB connection string to read transaction types from instead of the built in list")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var templatePacks *category_provider.TemplatePackRegistry
+	var err error
+	if *packsDirectory != "" {
+		templatePacks, err = category_provider.LoadTemplatePacks(os.DirFS(*packsDirectory))
+	} else {
+		templatePacks, err = category_provider.LoadEmbeddedTemplatePacks()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load template packs: %v\n", err)
+		return 1
+	}
+
+	validator := category_provider.NewTemplateValidator()
+	if *databaseUrl != "" {
+		connection, err := pgx.Connect(context.Background(), *databaseUrl)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to connect to database: %v\n", err)
+			return 1
+		}
+		defer connection.Close(context.Background())
+
+		transactionTypesRepository := &store.CockroachDbTransactionTypesRepository{Connection: connection}
+		validator.TransactionTypes, err = transactionTypesRepository.GetTransactionTypes(context.Background())
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to read transaction types: %v\n", err)
+			return 1
+		}
+	}
+
+	exitCode := 0
+	for _, pack := range templatePacks.List() {
+		issues := validator.ValidatePack(pack)
+		if len(issues) == 0 {
+			fmt.Fprintf(stdout, "%s: ok\n", pack.TemplateVersion())
+			continue
+		}
+
+		exitCode = 1
+		for _, issue := range issues {
+			fmt.Fprintf(stdout, "%s: %s\n", pack.TemplateVersion(), issue)
+		}
+	}
+
+	return exitCode
+}
@@ -0,0 +1,51 @@
+//go:build !integrationTest
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunLint(t *testing.T) {
+	t.Run("given embedded template packs, when lint run, then every pack reported ok", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runLint([]string{}, &stdout, &stderr)
+
+		assert.Equal(t, 0, exitCode)
+		assert.Contains(t, stdout.String(), "au-default@1: ok")
+	})
+
+	t.Run("given template pack with issues, when lint run, then issues reported and non-zero exit code returned", func(t *testing.T) {
+		packsDirectory := t.TempDir()
+		os.WriteFile(filepath.Join(packsDirectory, "test@1.json"), []byte(`{
+			"name": "test",
+			"version": 1,
+			"categories": [{"transactionType": "expense", "name": "Category1", "subcategories": ["Subcategory1", "subcategory1"]}],
+			"tags": ["Holiday", "holiday"],
+			"payerPayees": [{"name": "Coles", "type": "payee"}, {"name": "Coles ", "type": "payee"}, {"name": "Employer", "type": "employer"}]
+		}`), 0644)
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runLint([]string{"-dir", packsDirectory}, &stdout, &stderr)
+
+		assert.Equal(t, 1, exitCode)
+		assert.Equal(t, "test@1: expense > Category1 > subcategory1: duplicate subcategory\n"+
+			"test@1: tags > holiday: duplicate tag\n"+
+			"test@1: payerPayees > Coles : duplicate payee\n"+
+			"test@1: payerPayees > Employer: unknown type \"employer\", expected payer or payee\n", stdout.String())
+	})
+
+	t.Run("given unknown flag, when lint run, then usage error returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runLint([]string{"-unknown"}, &stdout, &stderr)
+
+		assert.Equal(t, 2, exitCode)
+	})
+}
@@ -2,97 +2,291 @@ package main
 
 import (
 	"categoryInitialiser/category_provider"
+	"categoryInitialiser/config"
+	"categoryInitialiser/event_adapter"
 	"categoryInitialiser/models"
 	"categoryInitialiser/request_handler"
 	"categoryInitialiser/store"
 	"context"
-	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"os"
+	"sync"
 
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func Handle(request models.IntialiseCategoriesRequest) (err error) {
-	categoryProvider, categoriesRepository, err := setupDependencies(request)
+// The configuration and the pool created from it are kept for the lifetime of the Lambda container so warm
+// invocations reuse open connections instead of fetching secrets and reconnecting every time
+var (
+	dependenciesMutex sync.Mutex
+	initialiserConfig *config.Config
+	cockroachDbPool   *pgxpool.Pool
+)
+
+func Handle(ctx context.Context, request models.IntialiseCategoriesRequest) (response models.InitialiseCategoriesResponse, err error) {
+	cfg, pool, err := getDependencies(ctx)
 	if err != nil {
-		return err
+		return
 	}
 
-	err = request_handler.HandleRequest(categoryProvider, categoriesRepository)
+	return initialiseCategories(ctx, cfg, pool, request)
+}
+
+// HandleAuth0Registration resolves or creates the user and their default profile and then initialises its categories
+// like any other request. The user and profile are committed in a transaction of their own first, since the profile
+// lock references the profile and can't be taken on another connection while the profile is uncommitted. A sign up
+// whose initialisation fails is left with an empty default profile, which the hook's retry or the backfill command
+// initialises.
+func HandleAuth0Registration(ctx context.Context, user models.Auth0User) (response models.InitialiseCategoriesResponse, err error) {
+	cfg, pool, err := getDependencies(ctx)
 	if err != nil {
+		return
+	}
+
+	var userId, profileId string
+	err = store.ExecuteInTransaction(ctx, pool, func(tx pgx.Tx) error {
+		userProfilesRepository := &store.CockroachDbUserProfilesRepository{Connection: tx}
+
+		var err error
+		userId, profileId, err = userProfilesRepository.GetOrCreateUserWithDefaultProfile(ctx, event_adapter.UserIdentifier(user))
 		return err
+	})
+	if err != nil {
+		return
+	}
+
+	return initialiseCategories(ctx, cfg, pool, models.IntialiseCategoriesRequest{
+		UserId:    userId,
+		ProfileId: profileId,
+	})
+}
+
+// AuthoriseCaller returns the id of the user with userIdentifier if they belong to profileId
+func AuthoriseCaller(ctx context.Context, userIdentifier string, profileId string) (string, error) {
+	_, pool, err := getDependencies(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	userProfilesRepository := &store.CockroachDbUserProfilesRepository{Connection: pool}
+	return userProfilesRepository.GetUserIdForProfile(ctx, userIdentifier, profileId)
+}
+
+// HandleEvent is the Lambda entrypoint. It accepts an IntialiseCategoriesRequest, the user payload sent by the Auth0
+// post user registration hook, an SQS batch, an EventBridge ProfileCreated event or an API Gateway proxy request.
+// Work stops DeadlineSafetyMargin before the Lambda deadline so that it can be rolled back before Lambda kills it.
+func HandleEvent(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	ctx, cancel := request_handler.WithDeadlineSafetyMargin(ctx, request_handler.DeadlineSafetyMargin)
+	defer cancel()
+
+	router := event_adapter.EventRouter{
+		Initialise:          Handle,
+		InitialiseAuth0User: HandleAuth0Registration,
+		AuthoriseCaller:     AuthoriseCaller,
 	}
 
+	return router.Route(ctx, payload)
+}
+
+// initialiseCategories saves the categories, tags and payers and payees in one transaction on pool. The profile lock is
+// taken on another connection of pool before that transaction begins and released after it has committed or rolled
+// back, so a second initialisation of the profile waits until it can see everything this one wrote.
+func initialiseCategories(ctx context.Context, cfg config.Config, pool *pgxpool.Pool, request models.IntialiseCategoriesRequest) (response models.InitialiseCategoriesResponse, err error) {
+	err = request_handler.WithProfileLock(ctx, newProfileLock(cfg, pool, request.ProfileId), func() error {
+		return store.ExecuteInTransaction(ctx, pool, func(tx pgx.Tx) error {
+			var err error
+			response, err = initialiseInTransaction(ctx, cfg, tx, request)
+			return err
+		})
+	})
+
 	return
 }
 
-//go:embed category_provider/data/expenseCategories.json
-var expenseCategories []byte
+// initialiseInTransaction initialises the profile in tx, the caller must hold the profile lock
+func initialiseInTransaction(ctx context.Context, cfg config.Config, tx pgx.Tx, request models.IntialiseCategoriesRequest) (models.InitialiseCategoriesResponse, error) {
+	categoryProvider, categoriesRepository, tagsRepository, payerPayeesRepository, err := setupDependencies(cfg, tx, request)
+	if err != nil {
+		return models.InitialiseCategoriesResponse{}, err
+	}
+
+	if !request.DryRun {
+		return request_handler.HandleRequest(ctx, categoryProvider, categoriesRepository, tagsRepository, payerPayeesRepository)
+	}
 
-//go:embed category_provider/data/incomeCategories.json
-var incomeCategories []byte
+	plan, err := request_handler.PlanRequest(ctx, categoryProvider, categoriesRepository, tagsRepository, payerPayeesRepository)
+	if err != nil {
+		return models.InitialiseCategoriesResponse{}, err
+	}
+	plan.ProfileId = request.ProfileId
 
-func setupDependencies(request models.IntialiseCategoriesRequest) (categoryProvider category_provider.CategoryProvider, categoriesRepository store.CategoriesRepository, err error) {
+	return models.InitialiseCategoriesResponse{TemplateVersion: plan.TemplateVersion, Plan: &plan}, nil
+}
+
+// newProfileLock returns the lock of profileId. Each of its statements runs on a connection of its own from pool and
+// commits straight away, which is what makes it visible to other initialisations.
+func newProfileLock(cfg config.Config, pool *pgxpool.Pool, profileId string) *store.CockroachDbProfileLock {
+	return &store.CockroachDbProfileLock{
+		Connection:    pool,
+		ProfileId:     profileId,
+		LeaseDuration: cfg.ProfileLockLeaseDuration,
+		WaitTimeout:   cfg.ProfileLockWaitTimeout,
+	}
+}
+
+// getDependencies returns the container's configuration and connection pool, creating them on first use. A failed
+// attempt is not cached so the next invocation tries again.
+func getDependencies(ctx context.Context) (config.Config, *pgxpool.Pool, error) {
+	dependenciesMutex.Lock()
+	defer dependenciesMutex.Unlock()
+
+	if initialiserConfig == nil {
+		configProvider, err := newConfigProvider(ctx)
+		if err != nil {
+			return config.Config{}, nil, err
+		}
+
+		cfg, err := config.Load(ctx, configProvider)
+		if err != nil {
+			return config.Config{}, nil, err
+		}
+		initialiserConfig = &cfg
+	}
+
+	if cockroachDbPool == nil {
+		pool, err := store.NewCockroachDbPool(initialiserConfig.CockroachDbConnectionString, initialiserConfig.CockroachDbPoolSize)
+		if err != nil {
+			return config.Config{}, nil, err
+		}
+		cockroachDbPool = pool
+	}
+
+	return *initialiserConfig, cockroachDbPool, nil
+}
+
+// newConfigProvider layers the configuration sources in order of precedence: environment variables, then the file
+// named by CATEGORY_INITIALISER_CONFIG_FILE, then outside of dev the secret named by CATEGORY_INITIALISER_SECRET_ID
+// and finally SSM Parameter Store
+func newConfigProvider(ctx context.Context) (config.Provider, error) {
 	environment, ok := os.LookupEnv("ENVIRONMENT")
 	if !ok {
-		err = errors.New("no ENVIRONMENT environment variable found")
-		return
+		return nil, errors.New("no ENVIRONMENT environment variable found")
+	}
+
+	providers := config.LayeredProvider{config.EnvProvider{Variables: config.DefaultEnvVariables}}
+
+	if configFile, ok := os.LookupEnv("CATEGORY_INITIALISER_CONFIG_FILE"); ok {
+		providers = append(providers, &config.FileProvider{Path: configFile})
 	}
 
-	var cockroachDbConnectionString string
 	if environment == "dev" {
-		var envVar = "CATEGORY_INITIALISER_COCKROACHDB_CONNECTION_STRING"
-		cockroachDbConnectionString, ok = os.LookupEnv(envVar)
-		if !ok {
-			err = errors.New("no CockroachDb connection string environment variable found")
-			return
+		return providers, nil
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	if secretId, ok := os.LookupEnv("CATEGORY_INITIALISER_SECRET_ID"); ok {
+		providers = append(providers, &config.SecretsManagerProvider{
+			Client:   secretsmanager.NewFromConfig(awsConfig),
+			SecretId: secretId,
+		})
+	}
+
+	return append(providers, config.SsmProvider{
+		Client:     ssm.NewFromConfig(awsConfig),
+		Parameters: config.SsmParameters(environment),
+	}), nil
+}
+
+// setupDependencies binds the category provider and repositories to transaction
+func setupDependencies(cfg config.Config, transaction store.DbConnection, request models.IntialiseCategoriesRequest) (categoryProvider category_provider.CategoryProvider, categoriesRepository store.CategoriesRepository, tagsRepository store.TagsRepository, payerPayeesRepository store.PayerPayeesRepository, err error) {
+	if request.Template != "" && request.SourceProfileId != "" {
+		err = errors.New("only one of Template and SourceProfileId can be provided")
+		return
+	}
+
+	if request.SourceProfileId != "" {
+		categoryProvider = &category_provider.ProfileCategoryProvider{
+			Connection:      transaction,
+			UserId:          request.UserId,
+			SourceProfileId: request.SourceProfileId,
 		}
 	} else {
-		cfg, err := config.LoadDefaultConfig(context.TODO())
+		templatePacks, err := category_provider.LoadEmbeddedTemplatePacks()
 		if err != nil {
-			panic("configuration error, " + err.Error())
+			return nil, nil, nil, nil, err
 		}
-		client := ssm.NewFromConfig(cfg)
 
-		parameterResults, err := client.GetParameter(context.Background(), &ssm.GetParameterInput{
-			Name:           aws.String(fmt.Sprintf("/%s/categoryInitialiser/cockroachDbConnectionString", environment)),
-			WithDecryption: aws.Bool(true),
-		})
+		template := request.Template
+		if template == "" {
+			template = cfg.DefaultTemplate
+		}
 
+		templatePack, err := templatePacks.Get(template)
 		if err != nil {
-			log.Fatal(err)
+			return nil, nil, nil, nil, err
 		}
-		cockroachDbConnectionString = *parameterResults.Parameter.Value
-	}
 
-	cockroachDbConnection, err := pgx.Connect(context.Background(), cockroachDbConnectionString)
-	if err != nil {
-		return nil, nil, err
+		categoryProvider = &category_provider.TemplatePackCategoryProvider{
+			Pack: templatePack,
+		}
 	}
 
 	categoriesRepository = &store.CockroachDbCategoriesRepository{
-		Connection: cockroachDbConnection,
+		Connection: transaction,
 		UserId:     request.UserId,
 		ProfileId:  request.ProfileId,
+		Mode:       store.MergeMode,
+		DryRun:     request.DryRun,
 	}
 
-	categoryProvider = &category_provider.JsonCategoryProvider{
-		CategoryJsonBytes: map[string][]byte{
-			"expense": expenseCategories,
-			"income":  incomeCategories,
-		},
+	tagsRepository = &store.CockroachDbTagsRepository{
+		Connection: transaction,
+		ProfileId:  request.ProfileId,
+		DryRun:     request.DryRun,
+	}
+
+	payerPayeesRepository = &store.CockroachDbPayerPayeesRepository{
+		Connection: transaction,
+		UserId:     request.UserId,
+		ProfileId:  request.ProfileId,
+		DryRun:     request.DryRun,
 	}
 
 	return
 }
 
 func main() {
-	lambda.Start(Handle)
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	lambda.Start(HandleEvent)
+}
+
+// runCommand runs one of the maintenance subcommands used outside of Lambda
+func runCommand(command string, args []string) int {
+	switch command {
+	case "lint":
+		return runLint(args, os.Stdout, os.Stderr)
+	case "initialise":
+		return runInitialise(args, os.Stdout, os.Stderr)
+	case "sync":
+		return runSync(args, os.Stdout, os.Stderr)
+	case "backfill":
+		return runBackfill(args, os.Stdout, os.Stderr)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q, available commands: lint, initialise, sync, backfill\n", command)
+		return 2
+	}
 }
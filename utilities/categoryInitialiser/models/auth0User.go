@@ -0,0 +1,9 @@
+package models
+
+// Auth0User is the user object Auth0 passes to post user registration hooks
+type Auth0User struct {
+	Id       string `json:"id"`
+	Tenant   string `json:"tenant"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// BackfillReport lists the result of initialising each profile that had no categories
+type BackfillReport struct {
+	ProfilesFound int
+	Succeeded     []BackfillSuccess
+	Failed        []BackfillFailure
+}
+
+type BackfillSuccess struct {
+	ProfileId                string
+	UserId                   string
+	TemplateVersion          string
+	InsertedCategoryCount    int
+	InsertedSubcategoryCount int
+}
+
+type BackfillFailure struct {
+	ProfileId string
+	UserId    string
+	Error     string
+}
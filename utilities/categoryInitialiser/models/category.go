@@ -4,4 +4,6 @@ type Category struct {
 	CategoryName    string
 	Subcategories   []string
 	TransactionType string
+	// SortOrder is the display position of the category among categories of the same transaction type
+	SortOrder int
 }
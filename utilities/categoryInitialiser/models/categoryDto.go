@@ -1,7 +1,7 @@
 package models
 
 type CategoryDto struct {
-	CategoryName string
-	CategoryType string
-	Subcategories []string
+	CategoryName  string   `json:"name"`
+	CategoryType  string   `json:"transactionType"`
+	Subcategories []string `json:"subcategories"`
 }
@@ -0,0 +1,44 @@
+package models
+
+// InitialisationPlan describes what initialising a profile's categories would write, without writing it
+type InitialisationPlan struct {
+	ProfileId       string
+	TemplateVersion string
+	// Create lists new categories, and existing categories that would gain subcategories, with the subcategories to create
+	Create []PlannedCategory
+	// Skip lists existing categories with the subcategories that already exist and would be left alone
+	Skip []PlannedCategory
+	// Conflicts are existing categories and subcategories that look like a template entry but won't be matched to it
+	Conflicts             []PlanConflict
+	CategoriesToCreate    int
+	SubcategoriesToCreate int
+	CategoriesToSkip      int
+	SubcategoriesToSkip   int
+	TagsToCreate          []string
+	TagsToSkip            []string
+	PayerPayeesToCreate   []PayerPayeeDto
+	PayerPayeesToSkip     []PayerPayeeDto
+}
+
+type PlannedCategory struct {
+	TransactionType string
+	Name            string
+	Exists          bool
+	Subcategories   []string
+}
+
+type PlanConflict struct {
+	TransactionType string
+	Category        string
+	Subcategory     string
+	Message         string
+}
+
+func (c PlanConflict) String() string {
+	location := c.TransactionType + " > " + c.Category
+	if c.Subcategory != "" {
+		location += " > " + c.Subcategory
+	}
+
+	return location + ": " + c.Message
+}
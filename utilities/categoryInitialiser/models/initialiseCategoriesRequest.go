@@ -3,4 +3,10 @@ package models
 type IntialiseCategoriesRequest struct {
 	UserId    string
 	ProfileId string
+	// Template optionally selects a category template pack as "name" or "name@version", defaulting to the latest default pack
+	Template string
+	// SourceProfileId optionally copies the categories of another profile the user belongs to instead of using a template
+	SourceProfileId string
+	// DryRun works out what would be written and returns it as a plan, rolling back instead of committing
+	DryRun bool
 }
@@ -0,0 +1,43 @@
+package models
+
+type InitialiseCategoriesResponse struct {
+	// CreatedCategories is keyed by transaction type. Existing categories only appear if subcategories were added to them
+	CreatedCategories        map[string][]CreatedCategory
+	InsertedCategoryCount    int
+	SkippedCategoryCount     int
+	InsertedSubcategoryCount int
+	SkippedSubcategoryCount  int
+	CreatedTags              []CreatedTag
+	InsertedTagCount         int
+	SkippedTagCount          int
+	CreatedPayerPayees       []CreatedPayerPayee
+	InsertedPayerPayeeCount  int
+	SkippedPayerPayeeCount   int
+	TemplateVersion          string
+	DurationMilliseconds     int64
+	// Plan is only set for dry runs, which leave every other field apart from TemplateVersion empty
+	Plan *InitialisationPlan `json:",omitempty"`
+}
+
+type CreatedCategory struct {
+	Id            string
+	Name          string
+	Created       bool
+	Subcategories []CreatedSubcategory
+}
+
+type CreatedSubcategory struct {
+	Id   string
+	Name string
+}
+
+type CreatedTag struct {
+	Id   string
+	Name string
+}
+
+type CreatedPayerPayee struct {
+	Id   string
+	Name string
+	Type string
+}
@@ -0,0 +1,7 @@
+package models
+
+// PayerPayeeDto is a payer or payee a template seeds, Type is either "payer" or "payee"
+type PayerPayeeDto struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
@@ -0,0 +1,9 @@
+package models
+
+type Profile struct {
+	ProfileId string
+	// UserId is a user belonging to the profile, used as the owner of categories added to it
+	UserId string
+	// TemplateVersion is the template the profile's categories were initialised or last synced from, if recorded
+	TemplateVersion TemplateVersion
+}
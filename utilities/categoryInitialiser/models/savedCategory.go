@@ -0,0 +1,15 @@
+package models
+
+type SavedCategory struct {
+	Id              string
+	CategoryName    string
+	TransactionType string
+	Created         bool
+	Subcategories   []SavedSubcategory
+}
+
+type SavedSubcategory struct {
+	Id              string
+	SubcategoryName string
+	Created         bool
+}
@@ -0,0 +1,8 @@
+package models
+
+type SavedPayerPayee struct {
+	Id      string
+	Name    string
+	Type    string
+	Created bool
+}
@@ -0,0 +1,7 @@
+package models
+
+type SavedTag struct {
+	Id      string
+	Name    string
+	Created bool
+}
@@ -0,0 +1,27 @@
+package models
+
+// SyncReport summarises a template sync. It is saved with the sync's checkpoint so a resumed sync reports on every
+// profile, not only the ones processed since it resumed.
+type SyncReport struct {
+	TemplateVersion string
+	DryRun          bool
+	// Completed is false when the sync stopped before it went through every profile and can be resumed
+	Completed        bool
+	ProfilesScanned  int
+	ProfilesUpdated  int
+	ProfilesUpToDate int
+	ProfilesSkipped  int
+	// ProfilesWithoutTemplate counts profiles left alone because they have no recorded template and no baseline was given
+	ProfilesWithoutTemplate int
+	ProfilesFailed          int
+	CategoriesAdded         int
+	SubcategoriesAdded      int
+	// CustomisedEntriesKept counts template entries left out because the profile removed or renamed them
+	CustomisedEntriesKept int
+	Failures              []SyncFailure
+}
+
+type SyncFailure struct {
+	ProfileId string
+	Error     string
+}
@@ -0,0 +1,16 @@
+package models
+
+import "fmt"
+
+type TemplateVersion struct {
+	Name    string
+	Version int
+}
+
+func (t TemplateVersion) String() string {
+	if t.Name == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s@%d", t.Name, t.Version)
+}
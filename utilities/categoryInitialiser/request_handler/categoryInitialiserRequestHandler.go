@@ -4,29 +4,208 @@ import (
 	"categoryInitialiser/category_provider"
 	"categoryInitialiser/models"
 	"categoryInitialiser/store"
+	"context"
+	"log"
 	"strings"
+	"time"
 )
 
-func HandleRequest(categoryProvider category_provider.CategoryProvider, categoriesRepository store.CategoriesRepository) error {
-	categoryDtos, err := categoryProvider.GetCategories()
+// initialisation is what initialise saved for a profile
+type initialisation struct {
+	templateVersion  models.TemplateVersion
+	savedCategories  []models.SavedCategory
+	savedTags        []models.SavedTag
+	savedPayerPayees []models.SavedPayerPayee
+}
+
+// HandleRequest initialises the categories, and the tags and payers and payees if the category provider has any, for a
+// profile. The repositories should share a transaction so that the profile gets all of them or none, and the caller
+// should hold the profile lock until that transaction has finished, see WithProfileLock. Once
+// ctx is done any work in flight is abandoned and the save is rolled back, so callers should leave enough time before
+// their own deadline, see WithDeadlineSafetyMargin.
+func HandleRequest(ctx context.Context, categoryProvider category_provider.CategoryProvider, categoriesRepository store.CategoriesRepository, tagsRepository store.TagsRepository, payerPayeesRepository store.PayerPayeesRepository) (models.InitialiseCategoriesResponse, error) {
+	startTime := time.Now()
+
+	saved, err := initialise(ctx, categoryProvider, categoriesRepository, tagsRepository, payerPayeesRepository)
 	if err != nil {
-		return err
+		return models.InitialiseCategoriesResponse{}, err
+	}
+
+	response := buildResponse(saved.savedCategories, saved.savedTags, saved.savedPayerPayees)
+	response.TemplateVersion = saved.templateVersion.String()
+	response.DurationMilliseconds = time.Since(startTime).Milliseconds()
+
+	log.Printf("initialised categories from template %s: inserted %d categories, %d subcategories, %d tags and %d payers and payees, skipped %d existing categories, %d existing subcategories, %d existing tags and %d existing payers and payees",
+		response.TemplateVersion, response.InsertedCategoryCount, response.InsertedSubcategoryCount, response.InsertedTagCount, response.InsertedPayerPayeeCount,
+		response.SkippedCategoryCount, response.SkippedSubcategoryCount, response.SkippedTagCount, response.SkippedPayerPayeeCount)
+
+	return response, nil
+}
+
+// PlanRequest goes through the same steps as HandleRequest and describes what they wrote. The repositories must be in
+// dry run mode so that nothing is actually written.
+func PlanRequest(ctx context.Context, categoryProvider category_provider.CategoryProvider, categoriesRepository store.CategoriesRepository, tagsRepository store.TagsRepository, payerPayeesRepository store.PayerPayeesRepository) (models.InitialisationPlan, error) {
+	saved, err := initialise(ctx, categoryProvider, categoriesRepository, tagsRepository, payerPayeesRepository)
+	if err != nil {
+		return models.InitialisationPlan{}, err
+	}
+
+	existingCategories, err := categoriesRepository.GetCategories(ctx)
+	if err != nil {
+		return models.InitialisationPlan{}, err
+	}
+
+	plan := buildPlan(saved.savedCategories, existingCategories)
+	plan.TemplateVersion = saved.templateVersion.String()
+	addTagsToPlan(&plan, saved.savedTags)
+	addPayerPayeesToPlan(&plan, saved.savedPayerPayees)
+	return plan, nil
+}
+
+func initialise(ctx context.Context, categoryProvider category_provider.CategoryProvider, categoriesRepository store.CategoriesRepository, tagsRepository store.TagsRepository, payerPayeesRepository store.PayerPayeesRepository) (initialisation, error) {
+	if err := ctx.Err(); err != nil {
+		return initialisation{}, err
+	}
+
+	categoryDtos, err := categoryProvider.GetCategories(ctx)
+	if err != nil {
+		return initialisation{}, err
+	}
+
+	tags, err := getTags(ctx, categoryProvider)
+	if err != nil {
+		return initialisation{}, err
+	}
+
+	payerPayees, err := getPayerPayees(ctx, categoryProvider)
+	if err != nil {
+		return initialisation{}, err
 	}
 
 	categories := make([]models.Category, 0)
+	sortOrders := make(map[string]int)
 
 	for _, categoryDto := range categoryDtos {
+		transactionType := strings.ToLower(categoryDto.CategoryType)
 
 		categories = append(categories, models.Category{
 			CategoryName:    categoryDto.CategoryName,
 			Subcategories:   categoryDto.Subcategories,
-			TransactionType: strings.ToLower(categoryDto.CategoryType),
+			TransactionType: transactionType,
+			SortOrder:       sortOrders[transactionType],
 		})
+		sortOrders[transactionType]++
 	}
 
-	err = categoriesRepository.SaveCategories(categories)
+	saved := initialisation{
+		templateVersion:  categoryProvider.GetTemplateVersion(),
+		savedTags:        []models.SavedTag{},
+		savedPayerPayees: []models.SavedPayerPayee{},
+	}
+	saved.savedCategories, err = categoriesRepository.SaveCategories(ctx, saved.templateVersion, categories)
 	if err != nil {
-		return err
+		return initialisation{}, err
+	}
+
+	if len(tags) > 0 {
+		saved.savedTags, err = tagsRepository.SaveTags(ctx, tags)
+		if err != nil {
+			return initialisation{}, err
+		}
 	}
-	return nil
+
+	if len(payerPayees) > 0 {
+		saved.savedPayerPayees, err = payerPayeesRepository.SavePayerPayees(ctx, payerPayees)
+		if err != nil {
+			return initialisation{}, err
+		}
+	}
+
+	return saved, nil
+}
+
+// getTags returns the tags to seed, if categoryProvider has any
+func getTags(ctx context.Context, categoryProvider category_provider.CategoryProvider) ([]string, error) {
+	tagProvider, ok := categoryProvider.(category_provider.TagProvider)
+	if !ok {
+		return nil, nil
+	}
+
+	return tagProvider.GetTags(ctx)
+}
+
+// getPayerPayees returns the payers and payees to seed, if categoryProvider has any
+func getPayerPayees(ctx context.Context, categoryProvider category_provider.CategoryProvider) ([]models.PayerPayeeDto, error) {
+	payerPayeeProvider, ok := categoryProvider.(category_provider.PayerPayeeProvider)
+	if !ok {
+		return nil, nil
+	}
+
+	return payerPayeeProvider.GetPayerPayees(ctx)
+}
+
+func buildResponse(savedCategories []models.SavedCategory, savedTags []models.SavedTag, savedPayerPayees []models.SavedPayerPayee) models.InitialiseCategoriesResponse {
+	response := models.InitialiseCategoriesResponse{
+		CreatedCategories:  make(map[string][]models.CreatedCategory),
+		CreatedTags:        make([]models.CreatedTag, 0),
+		CreatedPayerPayees: make([]models.CreatedPayerPayee, 0),
+	}
+
+	for _, savedTag := range savedTags {
+		if !savedTag.Created {
+			response.SkippedTagCount++
+			continue
+		}
+
+		response.InsertedTagCount++
+		response.CreatedTags = append(response.CreatedTags, models.CreatedTag{Id: savedTag.Id, Name: savedTag.Name})
+	}
+
+	for _, savedPayerPayee := range savedPayerPayees {
+		if !savedPayerPayee.Created {
+			response.SkippedPayerPayeeCount++
+			continue
+		}
+
+		response.InsertedPayerPayeeCount++
+		response.CreatedPayerPayees = append(response.CreatedPayerPayees, models.CreatedPayerPayee{
+			Id:   savedPayerPayee.Id,
+			Name: savedPayerPayee.Name,
+			Type: savedPayerPayee.Type,
+		})
+	}
+
+	for _, savedCategory := range savedCategories {
+		createdCategory := models.CreatedCategory{
+			Id:            savedCategory.Id,
+			Name:          savedCategory.CategoryName,
+			Created:       savedCategory.Created,
+			Subcategories: make([]models.CreatedSubcategory, 0),
+		}
+
+		if savedCategory.Created {
+			response.InsertedCategoryCount++
+		} else {
+			response.SkippedCategoryCount++
+		}
+
+		for _, savedSubcategory := range savedCategory.Subcategories {
+			if !savedSubcategory.Created {
+				response.SkippedSubcategoryCount++
+				continue
+			}
+
+			response.InsertedSubcategoryCount++
+			createdCategory.Subcategories = append(createdCategory.Subcategories, models.CreatedSubcategory{
+				Id:   savedSubcategory.Id,
+				Name: savedSubcategory.SubcategoryName,
+			})
+		}
+
+		if createdCategory.Created || len(createdCategory.Subcategories) > 0 {
+			response.CreatedCategories[savedCategory.TransactionType] = append(response.CreatedCategories[savedCategory.TransactionType], createdCategory)
+		}
+	}
+
+	return response
 }
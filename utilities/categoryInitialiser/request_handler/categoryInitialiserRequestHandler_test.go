@@ -4,8 +4,11 @@ package request_handler
 
 import (
 	"categoryInitialiser/models"
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -13,18 +16,66 @@ type MockCategoryProvider struct {
 	mock.Mock
 }
 
-func (p *MockCategoryProvider) GetCategories() ([]models.CategoryDto, error) {
+func (p *MockCategoryProvider) GetCategories(context.Context) ([]models.CategoryDto, error) {
 	args := p.Called()
 	return args.Get(0).([]models.CategoryDto), args.Error(1)
 }
 
+func (p *MockCategoryProvider) GetTemplateVersion() models.TemplateVersion {
+	args := p.Called()
+	return args.Get(0).(models.TemplateVersion)
+}
+
 type MockCategoryRepository struct {
 	mock.Mock
 }
 
-func (r *MockCategoryRepository) SaveCategories(categories []models.Category) error {
-	args := r.Called(categories)
-	return args.Error(0)
+func (r *MockCategoryRepository) SaveCategories(_ context.Context, template models.TemplateVersion, categories []models.Category) ([]models.SavedCategory, error) {
+	args := r.Called(template, categories)
+	return args.Get(0).([]models.SavedCategory), args.Error(1)
+}
+
+func (r *MockCategoryRepository) GetCategories(context.Context) ([]models.Category, error) {
+	args := r.Called()
+	return args.Get(0).([]models.Category), args.Error(1)
+}
+
+// MockTagProvider is a category provider whose template also has tags
+type MockTagProvider struct {
+	MockCategoryProvider
+}
+
+func (p *MockTagProvider) GetTags(context.Context) ([]string, error) {
+	args := p.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type MockTagsRepository struct {
+	mock.Mock
+}
+
+func (r *MockTagsRepository) SaveTags(_ context.Context, tags []string) ([]models.SavedTag, error) {
+	args := r.Called(tags)
+	return args.Get(0).([]models.SavedTag), args.Error(1)
+}
+
+// MockPayerPayeeProvider is a category provider whose template also has payers and payees
+type MockPayerPayeeProvider struct {
+	MockCategoryProvider
+}
+
+func (p *MockPayerPayeeProvider) GetPayerPayees(context.Context) ([]models.PayerPayeeDto, error) {
+	args := p.Called()
+	return args.Get(0).([]models.PayerPayeeDto), args.Error(1)
+}
+
+type MockPayerPayeesRepository struct {
+	mock.Mock
+}
+
+func (r *MockPayerPayeesRepository) SavePayerPayees(_ context.Context, payerPayees []models.PayerPayeeDto) ([]models.SavedPayerPayee, error) {
+	args := r.Called(payerPayees)
+	return args.Get(0).([]models.SavedPayerPayee), args.Error(1)
 }
 
 func TestHandleRequest(t *testing.T) {
@@ -33,9 +84,10 @@ func TestHandleRequest(t *testing.T) {
 		var mockCategoriesRepository = new(MockCategoryRepository)
 
 		mockCategoryProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
-		mockCategoriesRepository.On("SaveCategories", mock.Anything).Return(nil)
+		mockCategoryProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
 
-		err := HandleRequest(mockCategoryProvider, mockCategoriesRepository)
+		_, err := HandleRequest(context.Background(), mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), new(MockPayerPayeesRepository))
 		if err != nil {
 			t.Errorf("HandleRequest returned error %+v when not expecting error", err)
 		}
@@ -65,9 +117,10 @@ func TestHandleRequest(t *testing.T) {
 		}
 
 		mockCategoryProvider.On("GetCategories").Return(categoryDtos, nil)
-		mockCategoriesRepository.On("SaveCategories", mock.Anything).Return(nil)
+		mockCategoryProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
 
-		err := HandleRequest( mockCategoryProvider, mockCategoriesRepository)
+		_, err := HandleRequest(context.Background(), mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), new(MockPayerPayeesRepository))
 		if err != nil {
 			t.Errorf("HandleRequest returned error %+v when not expecting error", err)
 		}
@@ -85,6 +138,329 @@ func TestHandleRequest(t *testing.T) {
 			},
 		}
 
-		mockCategoriesRepository.AssertCalled(t, "SaveCategories", expectedCategories)
+		mockCategoriesRepository.AssertCalled(t, "SaveCategories", models.TemplateVersion{Name: "test", Version: 1}, expectedCategories)
+	})
+
+	t.Run("given SaveCategories fails, when HandleRequest called, then error returned", func(t *testing.T) {
+		var mockCategoryProvider = new(MockCategoryProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+		var expectedErr = errors.New("save failed")
+
+		mockCategoryProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockCategoryProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, expectedErr)
+
+		_, err := HandleRequest(context.Background(), mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), new(MockPayerPayeesRepository))
+		if !errors.Is(err, expectedErr) {
+			t.Errorf("HandleRequest returned error %+v when expecting %+v", err, expectedErr)
+		}
+
+	})
+
+	t.Run("given cancelled context, when HandleRequest called, then context error returned and nothing saved", func(t *testing.T) {
+		var mockCategoryProvider = new(MockCategoryProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := HandleRequest(ctx, mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), new(MockPayerPayeesRepository))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("HandleRequest returned error %+v when expecting %+v", err, context.Canceled)
+		}
+
+		mockCategoryProvider.AssertNotCalled(t, "GetCategories")
+		mockCategoriesRepository.AssertNotCalled(t, "SaveCategories", mock.Anything, mock.Anything)
+	})
+
+	t.Run("given context cancelled while saving, when HandleRequest called, then context error returned", func(t *testing.T) {
+		var mockCategoryProvider = new(MockCategoryProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		mockCategoryProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockCategoryProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) { cancel() }).
+			Return([]models.SavedCategory{}, context.Canceled)
+
+		_, err := HandleRequest(ctx, mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), new(MockPayerPayeesRepository))
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("HandleRequest returned error %+v when expecting %+v", err, context.Canceled)
+		}
+
+	})
+
+	t.Run("given saved categories, when HandleRequest called, then response contains created items grouped by transaction type and counts", func(t *testing.T) {
+		var mockCategoryProvider = new(MockCategoryProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+
+		mockCategoryProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockCategoryProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "au-default", Version: 3})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{
+			{
+				Id:              "category-1",
+				CategoryName:    "Category1",
+				TransactionType: "expense",
+				Created:         true,
+				Subcategories: []models.SavedSubcategory{
+					{Id: "subcategory-1", SubcategoryName: "Subcategory1", Created: true},
+				},
+			},
+			{
+				Id:              "category-2",
+				CategoryName:    "Category2",
+				TransactionType: "expense",
+				Created:         false,
+				Subcategories: []models.SavedSubcategory{
+					{Id: "subcategory-2", SubcategoryName: "Subcategory2", Created: false},
+					{Id: "subcategory-3", SubcategoryName: "Subcategory3", Created: true},
+				},
+			},
+			{
+				Id:              "category-3",
+				CategoryName:    "Category3",
+				TransactionType: "income",
+				Created:         false,
+				Subcategories: []models.SavedSubcategory{
+					{Id: "subcategory-4", SubcategoryName: "Subcategory4", Created: false},
+				},
+			},
+			{
+				Id:              "category-4",
+				CategoryName:    "Category4",
+				TransactionType: "income",
+				Created:         true,
+				Subcategories:   []models.SavedSubcategory{},
+			},
+		}, nil)
+
+		response, err := HandleRequest(context.Background(), mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), new(MockPayerPayeesRepository))
+		assert.Nil(t, err)
+
+		assert.Equal(t, map[string][]models.CreatedCategory{
+			"expense": {
+				{
+					Id:      "category-1",
+					Name:    "Category1",
+					Created: true,
+					Subcategories: []models.CreatedSubcategory{
+						{Id: "subcategory-1", Name: "Subcategory1"},
+					},
+				},
+				{
+					Id:      "category-2",
+					Name:    "Category2",
+					Created: false,
+					Subcategories: []models.CreatedSubcategory{
+						{Id: "subcategory-3", Name: "Subcategory3"},
+					},
+				},
+			},
+			"income": {
+				{
+					Id:            "category-4",
+					Name:          "Category4",
+					Created:       true,
+					Subcategories: []models.CreatedSubcategory{},
+				},
+			},
+		}, response.CreatedCategories)
+		assert.Equal(t, 2, response.InsertedCategoryCount)
+		assert.Equal(t, 2, response.SkippedCategoryCount)
+		assert.Equal(t, 2, response.InsertedSubcategoryCount)
+		assert.Equal(t, 2, response.SkippedSubcategoryCount)
+		assert.Equal(t, "au-default@3", response.TemplateVersion)
+		assert.GreaterOrEqual(t, response.DurationMilliseconds, int64(0))
+	})
+
+	t.Run("given categories of several transaction types, when HandleRequest called, then sort order follows template order within each transaction type", func(t *testing.T) {
+		var mockCategoryProvider = new(MockCategoryProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+
+		mockCategoryProvider.On("GetCategories").Return([]models.CategoryDto{
+			{CategoryName: "Expense1", CategoryType: "expense", Subcategories: []string{}},
+			{CategoryName: "Income1", CategoryType: "income", Subcategories: []string{}},
+			{CategoryName: "Expense2", CategoryType: "Expense", Subcategories: []string{}},
+			{CategoryName: "Income2", CategoryType: "income", Subcategories: []string{}},
+		}, nil)
+		mockCategoryProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
+
+		_, err := HandleRequest(context.Background(), mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), new(MockPayerPayeesRepository))
+		assert.Nil(t, err)
+
+		mockCategoriesRepository.AssertCalled(t, "SaveCategories", models.TemplateVersion{Name: "test", Version: 1}, []models.Category{
+			{CategoryName: "Expense1", Subcategories: []string{}, TransactionType: "expense", SortOrder: 0},
+			{CategoryName: "Income1", Subcategories: []string{}, TransactionType: "income", SortOrder: 0},
+			{CategoryName: "Expense2", Subcategories: []string{}, TransactionType: "expense", SortOrder: 1},
+			{CategoryName: "Income2", Subcategories: []string{}, TransactionType: "income", SortOrder: 1},
+		})
+	})
+
+	t.Run("given template with tags, when HandleRequest called, then tags saved and reported", func(t *testing.T) {
+		var mockTagProvider = new(MockTagProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+		var mockTagsRepository = new(MockTagsRepository)
+
+		mockTagProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockTagProvider.On("GetTags").Return([]string{"Holiday", "Business"}, nil)
+		mockTagProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
+		mockTagsRepository.On("SaveTags", mock.Anything).Return([]models.SavedTag{
+			{Id: "tag-1", Name: "Holiday", Created: true},
+			{Id: "tag-2", Name: "Business", Created: false},
+		}, nil)
+
+		response, err := HandleRequest(context.Background(), mockTagProvider, mockCategoriesRepository, mockTagsRepository, new(MockPayerPayeesRepository))
+		assert.Nil(t, err)
+
+		mockTagsRepository.AssertCalled(t, "SaveTags", []string{"Holiday", "Business"})
+		assert.Equal(t, []models.CreatedTag{{Id: "tag-1", Name: "Holiday"}}, response.CreatedTags)
+		assert.Equal(t, 1, response.InsertedTagCount)
+		assert.Equal(t, 1, response.SkippedTagCount)
+	})
+
+	t.Run("given template without tags, when HandleRequest called, then SaveTags not called", func(t *testing.T) {
+		var mockTagProvider = new(MockTagProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+		var mockTagsRepository = new(MockTagsRepository)
+
+		mockTagProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockTagProvider.On("GetTags").Return([]string{}, nil)
+		mockTagProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
+
+		response, err := HandleRequest(context.Background(), mockTagProvider, mockCategoriesRepository, mockTagsRepository, new(MockPayerPayeesRepository))
+		assert.Nil(t, err)
+
+		mockTagsRepository.AssertNotCalled(t, "SaveTags", mock.Anything)
+		assert.Empty(t, response.CreatedTags)
+		assert.Equal(t, 0, response.InsertedTagCount)
+	})
+
+	t.Run("given SaveTags fails, when HandleRequest called, then error returned", func(t *testing.T) {
+		var mockTagProvider = new(MockTagProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+		var mockTagsRepository = new(MockTagsRepository)
+
+		mockTagProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockTagProvider.On("GetTags").Return([]string{"Holiday"}, nil)
+		mockTagProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
+		mockTagsRepository.On("SaveTags", mock.Anything).Return([]models.SavedTag{}, errors.New("tag insert failed"))
+
+		_, err := HandleRequest(context.Background(), mockTagProvider, mockCategoriesRepository, mockTagsRepository, new(MockPayerPayeesRepository))
+
+		assert.EqualError(t, err, "tag insert failed")
+	})
+
+	t.Run("given template with payers and payees, when HandleRequest called, then payers and payees saved and reported", func(t *testing.T) {
+		var mockPayerPayeeProvider = new(MockPayerPayeeProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+		var mockPayerPayeesRepository = new(MockPayerPayeesRepository)
+
+		payerPayees := []models.PayerPayeeDto{{Name: "Woolworths", Type: "payee"}, {Name: "Employer", Type: "payer"}}
+		mockPayerPayeeProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockPayerPayeeProvider.On("GetPayerPayees").Return(payerPayees, nil)
+		mockPayerPayeeProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
+		mockPayerPayeesRepository.On("SavePayerPayees", mock.Anything).Return([]models.SavedPayerPayee{
+			{Id: "payee-1", Name: "Woolworths", Type: "payee", Created: false},
+			{Id: "payer-1", Name: "Employer", Type: "payer", Created: true},
+		}, nil)
+
+		response, err := HandleRequest(context.Background(), mockPayerPayeeProvider, mockCategoriesRepository, new(MockTagsRepository), mockPayerPayeesRepository)
+		assert.Nil(t, err)
+
+		mockPayerPayeesRepository.AssertCalled(t, "SavePayerPayees", payerPayees)
+		assert.Equal(t, []models.CreatedPayerPayee{{Id: "payer-1", Name: "Employer", Type: "payer"}}, response.CreatedPayerPayees)
+		assert.Equal(t, 1, response.InsertedPayerPayeeCount)
+		assert.Equal(t, 1, response.SkippedPayerPayeeCount)
+	})
+
+	t.Run("given category provider without payers and payees, when HandleRequest called, then SavePayerPayees not called", func(t *testing.T) {
+		var mockCategoryProvider = new(MockCategoryProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+		var mockPayerPayeesRepository = new(MockPayerPayeesRepository)
+
+		mockCategoryProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockCategoryProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
+
+		response, err := HandleRequest(context.Background(), mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), mockPayerPayeesRepository)
+		assert.Nil(t, err)
+
+		mockPayerPayeesRepository.AssertNotCalled(t, "SavePayerPayees", mock.Anything)
+		assert.Empty(t, response.CreatedPayerPayees)
+	})
+
+	t.Run("given SavePayerPayees fails, when HandleRequest called, then error returned", func(t *testing.T) {
+		var mockPayerPayeeProvider = new(MockPayerPayeeProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+		var mockPayerPayeesRepository = new(MockPayerPayeesRepository)
+
+		mockPayerPayeeProvider.On("GetCategories").Return([]models.CategoryDto{}, nil)
+		mockPayerPayeeProvider.On("GetPayerPayees").Return([]models.PayerPayeeDto{{Name: "Coles", Type: "payee"}}, nil)
+		mockPayerPayeeProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "test", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{}, nil)
+		mockPayerPayeesRepository.On("SavePayerPayees", mock.Anything).Return([]models.SavedPayerPayee{}, errors.New("payer payee insert failed"))
+
+		_, err := HandleRequest(context.Background(), mockPayerPayeeProvider, mockCategoriesRepository, new(MockTagsRepository), mockPayerPayeesRepository)
+
+		assert.EqualError(t, err, "payer payee insert failed")
+	})
+}
+
+func TestPlanRequest(t *testing.T) {
+	t.Run("given dry run repository, when PlanRequest called, then plan built from saved and existing categories", func(t *testing.T) {
+		var mockCategoryProvider = new(MockCategoryProvider)
+		var mockCategoriesRepository = new(MockCategoryRepository)
+
+		mockCategoryProvider.On("GetCategories").Return([]models.CategoryDto{
+			{CategoryName: "Food", CategoryType: "expense", Subcategories: []string{"Groceries", "Coffee"}},
+			{CategoryName: "Salary", CategoryType: "income", Subcategories: []string{}},
+		}, nil)
+		mockCategoryProvider.On("GetTemplateVersion").Return(models.TemplateVersion{Name: "au-default", Version: 1})
+		mockCategoriesRepository.On("SaveCategories", mock.Anything, mock.Anything).Return([]models.SavedCategory{
+			{
+				Id: "1", CategoryName: "Food", TransactionType: "expense", Created: false,
+				Subcategories: []models.SavedSubcategory{
+					{Id: "2", SubcategoryName: "Groceries", Created: false},
+					{Id: "3", SubcategoryName: "Coffee", Created: true},
+				},
+			},
+			{Id: "4", CategoryName: "Salary", TransactionType: "income", Created: true, Subcategories: []models.SavedSubcategory{}},
+		}, nil)
+		mockCategoriesRepository.On("GetCategories").Return([]models.Category{
+			{CategoryName: "Food", TransactionType: "expense", Subcategories: []string{"Groceries"}},
+			{CategoryName: "Salary", TransactionType: "expense", Subcategories: []string{}},
+		}, nil)
+
+		plan, err := PlanRequest(context.Background(), mockCategoryProvider, mockCategoriesRepository, new(MockTagsRepository), new(MockPayerPayeesRepository))
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.InitialisationPlan{
+			TemplateVersion: "au-default@1",
+			Create: []models.PlannedCategory{
+				{TransactionType: "expense", Name: "Food", Exists: true, Subcategories: []string{"Coffee"}},
+				{TransactionType: "income", Name: "Salary", Exists: false, Subcategories: []string{}},
+			},
+			Skip: []models.PlannedCategory{
+				{TransactionType: "expense", Name: "Food", Exists: true, Subcategories: []string{"Groceries"}},
+			},
+			Conflicts: []models.PlanConflict{
+				{TransactionType: "income", Category: "Salary", Message: `category "Salary" already exists under transaction type expense`},
+			},
+			CategoriesToCreate:    1,
+			SubcategoriesToCreate: 1,
+			CategoriesToSkip:      1,
+			SubcategoriesToSkip:   1,
+			TagsToCreate:          []string{},
+			TagsToSkip:            []string{},
+			PayerPayeesToCreate:   []models.PayerPayeeDto{},
+			PayerPayeesToSkip:     []models.PayerPayeeDto{},
+		}, plan)
 	})
 }
@@ -0,0 +1,21 @@
+package request_handler
+
+import (
+	"context"
+	"time"
+)
+
+// DeadlineSafetyMargin is how long before the Lambda deadline the initialiser gives up, leaving time to roll back,
+// release the profile lock and respond before Lambda kills the invocation
+const DeadlineSafetyMargin = 3 * time.Second
+
+// WithDeadlineSafetyMargin returns a context that is done margin before ctx's deadline. A ctx without a deadline is
+// only wrapped so that the returned cancel func can always be called.
+func WithDeadlineSafetyMargin(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithDeadline(ctx, deadline.Add(-margin))
+}
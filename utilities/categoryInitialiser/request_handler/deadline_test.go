@@ -0,0 +1,46 @@
+//go:build !integrationTest
+
+package request_handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDeadlineSafetyMargin(t *testing.T) {
+	t.Run("given context with deadline, when WithDeadlineSafetyMargin called, then deadline brought forward by margin", func(t *testing.T) {
+		deadline := time.Now().Add(30 * time.Second)
+		parent, cancelParent := context.WithDeadline(context.Background(), deadline)
+		defer cancelParent()
+
+		ctx, cancel := WithDeadlineSafetyMargin(parent, 3*time.Second)
+		defer cancel()
+
+		actualDeadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.Equal(t, deadline.Add(-3*time.Second), actualDeadline)
+	})
+
+	t.Run("given deadline within margin, when WithDeadlineSafetyMargin called, then context already done", func(t *testing.T) {
+		parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+		defer cancelParent()
+
+		ctx, cancel := WithDeadlineSafetyMargin(parent, 3*time.Second)
+		defer cancel()
+
+		assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	})
+
+	t.Run("given context without deadline, when WithDeadlineSafetyMargin called, then no deadline set", func(t *testing.T) {
+		ctx, cancel := WithDeadlineSafetyMargin(context.Background(), 3*time.Second)
+
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+
+		cancel()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
@@ -0,0 +1,150 @@
+package request_handler
+
+import (
+	"categoryInitialiser/models"
+	"fmt"
+	"strings"
+)
+
+// buildPlan sorts the categories a dry run saved into those it would create and those it skipped, and looks for
+// existing categories and subcategories that a person would take to be the same as a new one
+func buildPlan(savedCategories []models.SavedCategory, existingCategories []models.Category) models.InitialisationPlan {
+	plan := models.InitialisationPlan{
+		Create:              make([]models.PlannedCategory, 0),
+		Skip:                make([]models.PlannedCategory, 0),
+		Conflicts:           make([]models.PlanConflict, 0),
+		TagsToCreate:        make([]string, 0),
+		TagsToSkip:          make([]string, 0),
+		PayerPayeesToCreate: make([]models.PayerPayeeDto, 0),
+		PayerPayeesToSkip:   make([]models.PayerPayeeDto, 0),
+	}
+
+	for _, savedCategory := range savedCategories {
+		toCreate := models.PlannedCategory{
+			TransactionType: savedCategory.TransactionType,
+			Name:            savedCategory.CategoryName,
+			Exists:          !savedCategory.Created,
+			Subcategories:   make([]string, 0),
+		}
+		toSkip := toCreate
+		toSkip.Subcategories = make([]string, 0)
+
+		for _, savedSubcategory := range savedCategory.Subcategories {
+			if savedSubcategory.Created {
+				toCreate.Subcategories = append(toCreate.Subcategories, savedSubcategory.SubcategoryName)
+			} else {
+				toSkip.Subcategories = append(toSkip.Subcategories, savedSubcategory.SubcategoryName)
+			}
+		}
+
+		if savedCategory.Created {
+			plan.CategoriesToCreate++
+			plan.Conflicts = append(plan.Conflicts, findCategoryConflicts(savedCategory, existingCategories)...)
+		} else {
+			plan.CategoriesToSkip++
+		}
+		plan.SubcategoriesToCreate += len(toCreate.Subcategories)
+		plan.SubcategoriesToSkip += len(toSkip.Subcategories)
+
+		for _, subcategory := range toCreate.Subcategories {
+			plan.Conflicts = append(plan.Conflicts, findSubcategoryConflicts(savedCategory, subcategory, existingCategories)...)
+		}
+
+		if savedCategory.Created || len(toCreate.Subcategories) > 0 {
+			plan.Create = append(plan.Create, toCreate)
+		}
+		if !savedCategory.Created {
+			plan.Skip = append(plan.Skip, toSkip)
+		}
+	}
+
+	return plan
+}
+
+func addTagsToPlan(plan *models.InitialisationPlan, savedTags []models.SavedTag) {
+	for _, savedTag := range savedTags {
+		if savedTag.Created {
+			plan.TagsToCreate = append(plan.TagsToCreate, savedTag.Name)
+		} else {
+			plan.TagsToSkip = append(plan.TagsToSkip, savedTag.Name)
+		}
+	}
+}
+
+func addPayerPayeesToPlan(plan *models.InitialisationPlan, savedPayerPayees []models.SavedPayerPayee) {
+	for _, savedPayerPayee := range savedPayerPayees {
+		payerPayee := models.PayerPayeeDto{Name: savedPayerPayee.Name, Type: savedPayerPayee.Type}
+		if savedPayerPayee.Created {
+			plan.PayerPayeesToCreate = append(plan.PayerPayeesToCreate, payerPayee)
+		} else {
+			plan.PayerPayeesToSkip = append(plan.PayerPayeesToSkip, payerPayee)
+		}
+	}
+}
+
+// findCategoryConflicts finds existing categories with the same name under another transaction type, or with a name
+// that only differs in case, which the new category would end up sitting next to
+func findCategoryConflicts(savedCategory models.SavedCategory, existingCategories []models.Category) []models.PlanConflict {
+	conflicts := make([]models.PlanConflict, 0)
+
+	for _, existingCategory := range existingCategories {
+		if !strings.EqualFold(existingCategory.CategoryName, savedCategory.CategoryName) {
+			continue
+		}
+
+		var message string
+		switch {
+		case existingCategory.TransactionType != savedCategory.TransactionType:
+			message = fmt.Sprintf("category %q already exists under transaction type %s", existingCategory.CategoryName, existingCategory.TransactionType)
+		case existingCategory.CategoryName != savedCategory.CategoryName:
+			message = fmt.Sprintf("differs only in case from existing category %q", existingCategory.CategoryName)
+		default:
+			continue
+		}
+
+		conflicts = append(conflicts, models.PlanConflict{
+			TransactionType: savedCategory.TransactionType,
+			Category:        savedCategory.CategoryName,
+			Message:         message,
+		})
+	}
+
+	return conflicts
+}
+
+// findSubcategoryConflicts finds existing subcategories of the same transaction type with the new subcategory's name
+// that sit under another category, e.g. because the user moved them, or whose name only differs in case
+func findSubcategoryConflicts(savedCategory models.SavedCategory, subcategory string, existingCategories []models.Category) []models.PlanConflict {
+	conflicts := make([]models.PlanConflict, 0)
+
+	for _, existingCategory := range existingCategories {
+		if existingCategory.TransactionType != savedCategory.TransactionType {
+			continue
+		}
+
+		for _, existingSubcategory := range existingCategory.Subcategories {
+			if !strings.EqualFold(existingSubcategory, subcategory) {
+				continue
+			}
+
+			var message string
+			switch {
+			case existingCategory.CategoryName != savedCategory.CategoryName:
+				message = fmt.Sprintf("subcategory %q already exists under category %q", existingSubcategory, existingCategory.CategoryName)
+			case existingSubcategory != subcategory:
+				message = fmt.Sprintf("differs only in case from existing subcategory %q", existingSubcategory)
+			default:
+				continue
+			}
+
+			conflicts = append(conflicts, models.PlanConflict{
+				TransactionType: savedCategory.TransactionType,
+				Category:        savedCategory.CategoryName,
+				Subcategory:     subcategory,
+				Message:         message,
+			})
+		}
+	}
+
+	return conflicts
+}
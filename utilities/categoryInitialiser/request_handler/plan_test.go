@@ -0,0 +1,56 @@
+//go:build !integrationTest
+
+package request_handler
+
+import (
+	"categoryInitialiser/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPlan(t *testing.T) {
+	t.Run("given new subcategory already under another category, when buildPlan called, then conflict reported", func(t *testing.T) {
+		plan := buildPlan([]models.SavedCategory{
+			{
+				CategoryName: "Food", TransactionType: "expense", Created: false,
+				Subcategories: []models.SavedSubcategory{{SubcategoryName: "Coffee", Created: true}},
+			},
+		}, []models.Category{
+			{CategoryName: "Food", TransactionType: "expense", Subcategories: []string{}},
+			{CategoryName: "Drinks", TransactionType: "expense", Subcategories: []string{"coffee"}},
+		})
+
+		assert.Equal(t, []models.PlanConflict{
+			{TransactionType: "expense", Category: "Food", Subcategory: "Coffee", Message: `subcategory "coffee" already exists under category "Drinks"`},
+		}, plan.Conflicts)
+	})
+
+	t.Run("given new category that differs only in case from existing category, when buildPlan called, then conflict reported", func(t *testing.T) {
+		plan := buildPlan([]models.SavedCategory{
+			{CategoryName: "Food", TransactionType: "expense", Created: true, Subcategories: []models.SavedSubcategory{}},
+		}, []models.Category{
+			{CategoryName: "food", TransactionType: "expense", Subcategories: []string{}},
+		})
+
+		assert.Equal(t, []models.PlanConflict{
+			{TransactionType: "expense", Category: "Food", Message: `differs only in case from existing category "food"`},
+		}, plan.Conflicts)
+	})
+
+	t.Run("given nothing to create, when buildPlan called, then every category skipped and no conflicts reported", func(t *testing.T) {
+		plan := buildPlan([]models.SavedCategory{
+			{
+				CategoryName: "Food", TransactionType: "expense", Created: false,
+				Subcategories: []models.SavedSubcategory{{SubcategoryName: "Groceries", Created: false}},
+			},
+		}, []models.Category{
+			{CategoryName: "Food", TransactionType: "expense", Subcategories: []string{"Groceries"}},
+		})
+
+		assert.Empty(t, plan.Create)
+		assert.Empty(t, plan.Conflicts)
+		assert.Equal(t, 1, plan.CategoriesToSkip)
+		assert.Equal(t, 1, plan.SubcategoriesToSkip)
+	})
+}
@@ -0,0 +1,44 @@
+package request_handler
+
+import (
+	"categoryInitialiser/store"
+	"context"
+	"errors"
+	"log"
+)
+
+var ErrInitialisationInProgress = errors.New("category initialisation is already in progress for this profile")
+
+// AcquireProfileLock takes profileLock, returning ErrInitialisationInProgress if another initialisation is holding it.
+// The lock must be on a connection outside of the transaction the initialisation writes in, otherwise other
+// initialisations can't see it until that transaction commits.
+func AcquireProfileLock(ctx context.Context, profileLock store.ProfileLock) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := profileLock.Acquire(ctx)
+	if errors.Is(err, store.ErrProfileLocked) {
+		return ErrInitialisationInProgress
+	}
+
+	return err
+}
+
+// ReleaseProfileLock releases profileLock. A failure is only logged since the lease expires on its own.
+func ReleaseProfileLock(ctx context.Context, profileLock store.ProfileLock) {
+	if err := profileLock.Release(ctx); err != nil {
+		log.Printf("failed to release profile lock, it will be released when its lease expires: %v", err)
+	}
+}
+
+// WithProfileLock runs fn while holding profileLock. fn should commit or roll back everything it writes before it
+// returns, so that the next initialisation of the profile sees all of it.
+func WithProfileLock(ctx context.Context, profileLock store.ProfileLock, fn func() error) error {
+	if err := AcquireProfileLock(ctx, profileLock); err != nil {
+		return err
+	}
+	defer ReleaseProfileLock(ctx, profileLock)
+
+	return fn()
+}
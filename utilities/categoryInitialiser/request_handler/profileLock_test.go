@@ -0,0 +1,99 @@
+//go:build !integrationTest
+
+package request_handler
+
+import (
+	"categoryInitialiser/store"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProfileLock struct {
+	mock.Mock
+}
+
+func (l *MockProfileLock) Acquire(context.Context) error {
+	args := l.Called()
+	return args.Error(0)
+}
+
+func (l *MockProfileLock) Release(context.Context) error {
+	args := l.Called()
+	return args.Error(0)
+}
+
+func newMockProfileLock() *MockProfileLock {
+	var mockProfileLock = new(MockProfileLock)
+	mockProfileLock.On("Acquire").Return(nil)
+	mockProfileLock.On("Release").Return(nil)
+	return mockProfileLock
+}
+
+func TestWithProfileLock(t *testing.T) {
+	t.Run("given fn succeeds, when WithProfileLock called, then profile lock acquired before fn and released after it", func(t *testing.T) {
+		var mockProfileLock = new(MockProfileLock)
+		var calls []string
+		mockProfileLock.On("Acquire").Run(func(mock.Arguments) { calls = append(calls, "Acquire") }).Return(nil)
+		mockProfileLock.On("Release").Run(func(mock.Arguments) { calls = append(calls, "Release") }).Return(nil)
+
+		err := WithProfileLock(context.Background(), mockProfileLock, func() error {
+			calls = append(calls, "fn")
+			return nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Acquire", "fn", "Release"}, calls)
+	})
+
+	t.Run("given profile locked by another initialisation, when WithProfileLock called, then ErrInitialisationInProgress returned and fn not run", func(t *testing.T) {
+		var mockProfileLock = new(MockProfileLock)
+		mockProfileLock.On("Acquire").Return(store.ErrProfileLocked)
+
+		err := WithProfileLock(context.Background(), mockProfileLock, func() error {
+			t.Error("fn run without the profile lock")
+			return nil
+		})
+
+		assert.ErrorIs(t, err, ErrInitialisationInProgress)
+		mockProfileLock.AssertNotCalled(t, "Release")
+	})
+
+	t.Run("given fn fails, when WithProfileLock called, then error returned and profile lock still released", func(t *testing.T) {
+		var mockProfileLock = newMockProfileLock()
+		var expectedErr = errors.New("save failed")
+
+		err := WithProfileLock(context.Background(), mockProfileLock, func() error { return expectedErr })
+
+		assert.ErrorIs(t, err, expectedErr)
+		mockProfileLock.AssertNumberOfCalls(t, "Release", 1)
+	})
+
+	t.Run("given cancelled context, when WithProfileLock called, then context error returned and lock not acquired", func(t *testing.T) {
+		var mockProfileLock = newMockProfileLock()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := WithProfileLock(ctx, mockProfileLock, func() error {
+			t.Error("fn run with a cancelled context")
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		mockProfileLock.AssertNotCalled(t, "Acquire")
+	})
+
+	t.Run("given release fails, when WithProfileLock called, then fn's result returned", func(t *testing.T) {
+		var mockProfileLock = new(MockProfileLock)
+		mockProfileLock.On("Acquire").Return(nil)
+		mockProfileLock.On("Release").Return(errors.New("connection lost"))
+
+		err := WithProfileLock(context.Background(), mockProfileLock, func() error { return nil })
+
+		assert.Nil(t, err)
+	})
+}
@@ -1,7 +1,22 @@
 package store
 
-import "categoryInitialiser/models"
+import (
+	"categoryInitialiser/models"
+	"context"
+)
+
+type SaveMode int
+
+const (
+	// InsertMode fails the whole save if any category or subcategory already exists for the profile
+	InsertMode SaveMode = iota
+	// MergeMode creates only the categories and subcategories that are missing and leaves existing ones alone
+	MergeMode
+)
 
 type CategoriesRepository interface {
-	SaveCategories([]models.Category) error
+	// SaveCategories saves categories for a profile and records the template they came from
+	SaveCategories(context.Context, models.TemplateVersion, []models.Category) ([]models.SavedCategory, error)
+	// GetCategories returns the categories the profile already has
+	GetCategories(context.Context) ([]models.Category, error)
 }
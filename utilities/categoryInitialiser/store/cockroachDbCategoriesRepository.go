@@ -3,41 +3,414 @@ package store
 import (
 	"categoryInitialiser/models"
 	"context"
-	"fmt"
+	"errors"
 
 	"github.com/jackc/pgx/v5"
 )
 
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
 type CockroachDbCategoriesRepository struct {
-	Connection *pgx.Conn
+	Connection DbConnection
 	UserId     string
 	ProfileId  string
+	Mode       SaveMode
+	// DryRun saves categories exactly as a real save would but rolls the transaction back instead of committing it
+	DryRun bool
+	// UpdateTemplateVersion replaces the template recorded for the profile instead of keeping the first one, for syncs
+	UpdateTemplateVersion bool
+}
+
+// categoryKey identifies a category within a profile
+type categoryKey struct {
+	TransactionType string
+	Name            string
+}
+
+// subcategoryKey identifies a subcategory within a profile
+type subcategoryKey struct {
+	CategoryId string
+	Name       string
 }
 
-func (c *CockroachDbCategoriesRepository) SaveCategories(categories []models.Category) error {
+func (c *CockroachDbCategoriesRepository) SaveCategories(ctx context.Context, template models.TemplateVersion, categories []models.Category) (savedCategories []models.SavedCategory, err error) {
+	err = ExecuteInTransaction(ctx, c.Connection, func(tx pgx.Tx) error {
+		if err := c.saveTemplateVersion(ctx, tx, template); err != nil {
+			return err
+		}
+
+		savedCategories, err = c.saveCategoriesBatched(ctx, tx, categories)
+		if err == nil && c.DryRun {
+			return errDryRun
+		}
+		return err
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	return savedCategories, nil
+}
+
+func (c *CockroachDbCategoriesRepository) GetCategories(ctx context.Context) ([]models.Category, error) {
+	rows, err := c.Connection.Query(ctx,
+		`SELECT c.name, tt.name, c.sort_order, sc.name
+		FROM category c
+		JOIN transactiontype tt ON tt.id = c.transaction_type_id
+		LEFT JOIN subcategory sc ON sc.category_id = c.id
+		WHERE c.profile_id = $1
+		ORDER BY tt.name, c.sort_order, c.name, sc.sort_order, sc.name`, c.ProfileId)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := make([]models.Category, 0)
+	var category models.Category
+	var subcategoryName *string
+	_, err = pgx.ForEachRow(rows, []any{&category.CategoryName, &category.TransactionType, &category.SortOrder, &subcategoryName}, func() error {
+		last := len(categories) - 1
+		if last < 0 || categories[last].CategoryName != category.CategoryName || categories[last].TransactionType != category.TransactionType {
+			categories = append(categories, models.Category{
+				CategoryName:    category.CategoryName,
+				TransactionType: category.TransactionType,
+				SortOrder:       category.SortOrder,
+				Subcategories:   []string{},
+			})
+			last++
+		}
+
+		if subcategoryName != nil {
+			categories[last].Subcategories = append(categories[last].Subcategories, *subcategoryName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// saveCategoriesBatched saves all categories in one statement and then all subcategories in another. A batch that
+// breaks a constraint can't say which category caused it, so it is rolled back to a savepoint and the categories
+// are saved one at a time instead to return a CategoryInsertError or SubcategoryInsertError naming it.
+func (c *CockroachDbCategoriesRepository) saveCategoriesBatched(ctx context.Context, tx pgx.Tx, categories []models.Category) (savedCategories []models.SavedCategory, err error) {
+	err = ExecuteInTransaction(ctx, tx, func(savepoint pgx.Tx) error {
+		categoryIds, err := c.insertCategoryBatch(ctx, savepoint, categories)
+		if err != nil {
+			return err
+		}
+
+		subcategoryIds, err := c.insertSubcategoryBatch(ctx, savepoint, categories, categoryIds)
+		if err != nil {
+			return err
+		}
+
+		savedCategories = buildSavedCategories(categories, categoryIds, subcategoryIds)
+		return nil
+	})
+
+	if isIntegrityConstraintViolation(err) {
+		return c.saveCategoriesRowByRow(ctx, tx, categories)
+	}
+
+	return savedCategories, err
+}
+
+// insertCategoryBatch inserts every category with a single UNNEST statement and returns their ids keyed by
+// transaction type and name. In merge mode ids of categories that already existed are looked up afterwards.
+func (c *CockroachDbCategoriesRepository) insertCategoryBatch(ctx context.Context, tx pgx.Tx, categories []models.Category) (savedIds[categoryKey], error) {
+	ids := newSavedIds[categoryKey]()
+	if len(categories) == 0 {
+		return ids, nil
+	}
+
+	names := make([]string, 0, len(categories))
+	transactionTypes := make([]string, 0, len(categories))
+	sortOrders := make([]int, 0, len(categories))
 	for _, category := range categories {
+		names = append(names, category.CategoryName)
+		transactionTypes = append(transactionTypes, category.TransactionType)
+		sortOrders = append(sortOrders, category.SortOrder)
+	}
 
-		var createdCategoryId string
-		err := c.Connection.QueryRow(context.Background(),
-			`WITH input (category_name, user_id, transaction_type_name, profile_id) as (VALUES($1::VARCHAR, $2::UUID, $3::VARCHAR, $4::UUID))
-			INSERT INTO category (name, user_id, transaction_type_id, profile_id)
-			SELECT input.category_name, input.user_id, tt.id, input.profile_id
+	onConflict := ""
+	if c.Mode == MergeMode {
+		onConflict = "ON CONFLICT (name, profile_id, transaction_type_id) DO NOTHING"
+	}
+
+	rows, err := tx.Query(ctx,
+		`WITH input AS (
+			SELECT * FROM UNNEST($1::VARCHAR[], $2::VARCHAR[], $3::INT[]) AS input (category_name, transaction_type_name, sort_order)
+		), inserted AS (
+			INSERT INTO category (name, user_id, transaction_type_id, profile_id, sort_order)
+			SELECT input.category_name, $4::UUID, tt.id, $5::UUID, input.sort_order
 			FROM input
 			LEFT JOIN transactiontype tt ON tt.name = input.transaction_type_name
-			RETURNING id`, category.CategoryName, c.UserId, category.TransactionType, c.ProfileId,
-		).Scan(&createdCategoryId)
+			`+onConflict+`
+			RETURNING id, name, transaction_type_id
+		)
+		SELECT inserted.id, inserted.name, tt.name
+		FROM inserted
+		JOIN transactiontype tt ON tt.id = inserted.transaction_type_id`,
+		names, transactionTypes, sortOrders, c.UserId, c.ProfileId)
+	if err != nil {
+		return ids, err
+	}
 
-		if err != nil {
-			return err
+	var id string
+	var key categoryKey
+	_, err = pgx.ForEachRow(rows, []any{&id, &key.Name, &key.TransactionType}, func() error {
+		ids.created[key] = id
+		return nil
+	})
+	if err != nil || len(ids.created) == len(categories) {
+		return ids, err
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT c.id, c.name, tt.name
+		FROM category c
+		JOIN transactiontype tt ON tt.id = c.transaction_type_id
+		WHERE c.profile_id = $1`, c.ProfileId)
+	if err != nil {
+		return ids, err
+	}
+
+	_, err = pgx.ForEachRow(rows, []any{&id, &key.Name, &key.TransactionType}, func() error {
+		if _, created := ids.created[key]; !created {
+			ids.existing[key] = id
+		}
+		return nil
+	})
+
+	return ids, err
+}
+
+// insertSubcategoryBatch inserts the subcategories of every category with a single UNNEST statement
+func (c *CockroachDbCategoriesRepository) insertSubcategoryBatch(ctx context.Context, tx pgx.Tx, categories []models.Category, categoryIds savedIds[categoryKey]) (savedIds[subcategoryKey], error) {
+	ids := newSavedIds[subcategoryKey]()
+
+	parentIds := make([]string, 0)
+	names := make([]string, 0)
+	sortOrders := make([]int, 0)
+	for _, category := range categories {
+		categoryId, _ := categoryIds.get(categoryKey{TransactionType: category.TransactionType, Name: category.CategoryName})
+		for sortOrder, subcategory := range category.Subcategories {
+			parentIds = append(parentIds, categoryId)
+			names = append(names, subcategory)
+			sortOrders = append(sortOrders, sortOrder)
+		}
+	}
+	if len(names) == 0 {
+		return ids, nil
+	}
+
+	onConflict := ""
+	if c.Mode == MergeMode {
+		onConflict = "ON CONFLICT (name, category_id) DO NOTHING"
+	}
+
+	rows, err := tx.Query(ctx,
+		`INSERT INTO subcategory (category_id, name, sort_order)
+		SELECT * FROM UNNEST($1::UUID[], $2::VARCHAR[], $3::INT[])
+		`+onConflict+`
+		RETURNING id, category_id, name`,
+		parentIds, names, sortOrders)
+	if err != nil {
+		return ids, err
+	}
+
+	var id string
+	var key subcategoryKey
+	_, err = pgx.ForEachRow(rows, []any{&id, &key.CategoryId, &key.Name}, func() error {
+		ids.created[key] = id
+		return nil
+	})
+	if err != nil || len(ids.created) == len(names) {
+		return ids, err
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT id, category_id, name FROM subcategory WHERE category_id = ANY($1::UUID[])`, parentIds)
+	if err != nil {
+		return ids, err
+	}
+
+	_, err = pgx.ForEachRow(rows, []any{&id, &key.CategoryId, &key.Name}, func() error {
+		if _, created := ids.created[key]; !created {
+			ids.existing[key] = id
 		}
+		return nil
+	})
+
+	return ids, err
+}
 
-		fmt.Printf("%v", createdCategoryId)
+// buildSavedCategories lays the saved ids out in the order categories were given. Only the first occurrence of a
+// category or subcategory listed twice counts as created, the same as saving them one at a time.
+func buildSavedCategories(categories []models.Category, categoryIds savedIds[categoryKey], subcategoryIds savedIds[subcategoryKey]) []models.SavedCategory {
+	savedCategories := make([]models.SavedCategory, 0, len(categories))
+
+	for _, category := range categories {
+		categoryId, created := categoryIds.take(categoryKey{TransactionType: category.TransactionType, Name: category.CategoryName})
+		savedCategory := models.SavedCategory{
+			Id:              categoryId,
+			CategoryName:    category.CategoryName,
+			TransactionType: category.TransactionType,
+			Created:         created,
+			Subcategories:   make([]models.SavedSubcategory, 0, len(category.Subcategories)),
+		}
 
 		for _, subcategory := range category.Subcategories {
-			c.Connection.Exec(context.Background(),
-				`INSERT INTO subcategory (name, category_id) VALUES($1, $2)`, subcategory, createdCategoryId)
+			subcategoryId, created := subcategoryIds.take(subcategoryKey{CategoryId: categoryId, Name: subcategory})
+			savedCategory.Subcategories = append(savedCategory.Subcategories, models.SavedSubcategory{
+				Id:              subcategoryId,
+				SubcategoryName: subcategory,
+				Created:         created,
+			})
 		}
+
+		savedCategories = append(savedCategories, savedCategory)
+	}
+
+	return savedCategories
+}
+
+// saveCategoriesRowByRow saves each category and then each of its subcategories with its own statement
+func (c *CockroachDbCategoriesRepository) saveCategoriesRowByRow(ctx context.Context, tx pgx.Tx, categories []models.Category) ([]models.SavedCategory, error) {
+	savedCategories := make([]models.SavedCategory, 0, len(categories))
+
+	for _, category := range categories {
+		categoryId, created, err := c.saveCategory(ctx, tx, category)
+		if err != nil {
+			return nil, &CategoryInsertError{
+				CategoryName:    category.CategoryName,
+				TransactionType: category.TransactionType,
+				Err:             err,
+			}
+		}
+
+		savedCategory := models.SavedCategory{
+			Id:              categoryId,
+			CategoryName:    category.CategoryName,
+			TransactionType: category.TransactionType,
+			Created:         created,
+			Subcategories:   make([]models.SavedSubcategory, 0, len(category.Subcategories)),
+		}
+
+		for sortOrder, subcategory := range category.Subcategories {
+			subcategoryId, created, err := c.saveSubcategory(ctx, tx, categoryId, subcategory, sortOrder)
+			if err != nil {
+				return nil, &SubcategoryInsertError{
+					CategoryName:    category.CategoryName,
+					SubcategoryName: subcategory,
+					Err:             err,
+				}
+			}
+
+			savedCategory.Subcategories = append(savedCategory.Subcategories, models.SavedSubcategory{
+				Id:              subcategoryId,
+				SubcategoryName: subcategory,
+				Created:         created,
+			})
+		}
+
+		savedCategories = append(savedCategories, savedCategory)
 	}
 
-	return nil
+	return savedCategories, nil
+}
+
+// saveTemplateVersion records the template a profile was first initialised from. Later saves leave it untouched
+// unless UpdateTemplateVersion is set.
+func (c *CockroachDbCategoriesRepository) saveTemplateVersion(ctx context.Context, tx pgx.Tx, template models.TemplateVersion) error {
+	if template.Name == "" {
+		return nil
+	}
+
+	if c.UpdateTemplateVersion {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO profilecategorytemplate (profile_id, template_name, template_version) VALUES ($1, $2, $3)
+			ON CONFLICT (profile_id) DO UPDATE SET template_name = excluded.template_name, template_version = excluded.template_version`,
+			c.ProfileId, template.Name, template.Version)
+		return err
+	}
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO profilecategorytemplate (profile_id, template_name, template_version) VALUES ($1, $2, $3)
+		ON CONFLICT (profile_id) DO NOTHING`, c.ProfileId, template.Name, template.Version)
+	return err
+}
+
+func (c *CockroachDbCategoriesRepository) saveCategory(ctx context.Context, tx pgx.Tx, category models.Category) (categoryId string, created bool, err error) {
+	if c.Mode != MergeMode {
+		err = tx.QueryRow(ctx,
+			`WITH input (category_name, user_id, transaction_type_name, profile_id, sort_order) as (VALUES($1::VARCHAR, $2::UUID, $3::VARCHAR, $4::UUID, $5::INT))
+			INSERT INTO category (name, user_id, transaction_type_id, profile_id, sort_order)
+			SELECT input.category_name, input.user_id, tt.id, input.profile_id, input.sort_order
+			FROM input
+			LEFT JOIN transactiontype tt ON tt.name = input.transaction_type_name
+			RETURNING id`, category.CategoryName, c.UserId, category.TransactionType, c.ProfileId, category.SortOrder,
+		).Scan(&categoryId)
+
+		return categoryId, err == nil, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`WITH input (category_name, user_id, transaction_type_name, profile_id, sort_order) as (VALUES($1::VARCHAR, $2::UUID, $3::VARCHAR, $4::UUID, $5::INT))
+		INSERT INTO category (name, user_id, transaction_type_id, profile_id, sort_order)
+		SELECT input.category_name, input.user_id, tt.id, input.profile_id, input.sort_order
+		FROM input
+		LEFT JOIN transactiontype tt ON tt.name = input.transaction_type_name
+		ON CONFLICT (name, profile_id, transaction_type_id) DO NOTHING
+		RETURNING id`, category.CategoryName, c.UserId, category.TransactionType, c.ProfileId, category.SortOrder,
+	).Scan(&categoryId)
+
+	if err == nil {
+		return categoryId, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", false, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`SELECT c.id
+		FROM category c
+		JOIN transactiontype tt ON tt.id = c.transaction_type_id
+		WHERE c.name = $1 AND c.profile_id = $2 AND tt.name = $3`, category.CategoryName, c.ProfileId, category.TransactionType,
+	).Scan(&categoryId)
+
+	return categoryId, false, err
+}
+
+func (c *CockroachDbCategoriesRepository) saveSubcategory(ctx context.Context, tx pgx.Tx, categoryId string, subcategory string, sortOrder int) (subcategoryId string, created bool, err error) {
+	if c.Mode != MergeMode {
+		err = tx.QueryRow(ctx,
+			`INSERT INTO subcategory (name, category_id, sort_order) VALUES($1, $2, $3) RETURNING id`, subcategory, categoryId, sortOrder,
+		).Scan(&subcategoryId)
+
+		return subcategoryId, err == nil, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO subcategory (name, category_id, sort_order) VALUES($1, $2, $3)
+		ON CONFLICT (name, category_id) DO NOTHING
+		RETURNING id`, subcategory, categoryId, sortOrder,
+	).Scan(&subcategoryId)
+
+	if err == nil {
+		return subcategoryId, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", false, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`SELECT id FROM subcategory WHERE name = $1 AND category_id = $2`, subcategory, categoryId,
+	).Scan(&subcategoryId)
+
+	return subcategoryId, false, err
 }
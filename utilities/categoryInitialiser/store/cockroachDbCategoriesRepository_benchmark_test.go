@@ -0,0 +1,83 @@
+//go:build integrationTest
+
+package store
+
+import (
+	"categoryInitialiser/models"
+	"categoryInitialiser/test_utils"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BenchmarkSaveCategories compares saving a default sized template one row at a time with saving it in batches.
+// Run with: go test -tags integrationTest -run '^$' -bench SaveCategories ./store
+func BenchmarkSaveCategories(b *testing.B) {
+	// Roughly the size of the au-default template, 8 expense categories and 1 income category with 58 subcategories
+	categories := make([]models.Category, 0, 9)
+	for i := 0; i < 9; i++ {
+		transactionType := "expense"
+		if i == 8 {
+			transactionType = "income"
+		}
+
+		subcategories := make([]string, 0, 7)
+		for j := 0; j < 6+i%3; j++ {
+			subcategories = append(subcategories, fmt.Sprintf("subcategory %d", j))
+		}
+
+		categories = append(categories, models.Category{
+			CategoryName:    fmt.Sprintf("category %d", i),
+			Subcategories:   subcategories,
+			TransactionType: transactionType,
+			SortOrder:       i,
+		})
+	}
+
+	saveFuncs := map[string]func(repo *CockroachDbCategoriesRepository, tx pgx.Tx) error{
+		"row by row": func(repo *CockroachDbCategoriesRepository, tx pgx.Tx) error {
+			_, err := repo.saveCategoriesRowByRow(context.Background(), tx, categories)
+			return err
+		},
+		"batched": func(repo *CockroachDbCategoriesRepository, tx pgx.Tx) error {
+			_, err := repo.saveCategoriesBatched(context.Background(), tx, categories)
+			return err
+		},
+	}
+
+	for name, save := range saveFuncs {
+		b.Run(name, func(b *testing.B) {
+			dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+			conn, err := pgx.Connect(context.Background(), dsn)
+			if err != nil {
+				b.Fatal(err)
+			}
+			cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+				Connection: conn,
+			}
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				cockroachDbHelpers.ClearData()
+				userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+				profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+				repo := &CockroachDbCategoriesRepository{
+					Connection: conn,
+					UserId:     userId,
+					ProfileId:  profileId,
+					Mode:       MergeMode,
+				}
+				b.StartTimer()
+
+				err := ExecuteInTransaction(context.Background(), conn, func(tx pgx.Tx) error {
+					return save(repo, tx)
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
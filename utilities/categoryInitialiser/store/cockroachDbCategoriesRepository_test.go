@@ -6,8 +6,10 @@ import (
 	"categoryInitialiser/models"
 	"categoryInitialiser/test_utils"
 	"context"
-	"sort"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
@@ -36,12 +38,13 @@ func Test(t *testing.T) {
 		inputCategory := models.Category{
 			CategoryName: "test",
 			Subcategories: []string{
-				"sub1", "sub2", "sub3",
+				"sub3", "sub1", "sub2",
 			},
 			TransactionType: "expense",
+			SortOrder:       4,
 		}
 
-		err := repo.SaveCategories([]models.Category{
+		_, err := repo.SaveCategories(context.Background(), models.TemplateVersion{}, []models.Category{
 			inputCategory,
 		})
 
@@ -51,15 +54,17 @@ func Test(t *testing.T) {
 		var name string
 		var transaction_type_id string
 		var profile_id string
+		var sortOrder int
 
-		conn.QueryRow(context.Background(), `SELECT * FROM category`).Scan(&categoryId, &name, &userId, &transaction_type_id, &profile_id)
+		conn.QueryRow(context.Background(), `SELECT id, name, user_id, transaction_type_id, profile_id, sort_order FROM category`).Scan(&categoryId, &name, &userId, &transaction_type_id, &profile_id, &sortOrder)
 		assert.Equal(t, inputCategory.CategoryName, name)
+		assert.Equal(t, inputCategory.SortOrder, sortOrder)
 
 		var transaction_type string
 		conn.QueryRow(context.Background(), `SELECT name FROM transactiontype WHERE id = $1`, transaction_type_id).Scan(&transaction_type)
 		assert.Equal(t, inputCategory.TransactionType, transaction_type)
 
-		subcategoryRows, err := conn.Query(context.Background(), `SELECT name FROM subcategory WHERE category_id = $1`, categoryId)
+		subcategoryRows, err := conn.Query(context.Background(), `SELECT name FROM subcategory WHERE category_id = $1 ORDER BY sort_order`, categoryId)
 		assert.Nil(t, err)
 
 		defer subcategoryRows.Close()
@@ -72,10 +77,316 @@ func Test(t *testing.T) {
 		})
 		assert.Nil(t, err)
 
-		sort.Slice(subcategories, func(i, j int) bool {
-			return subcategories[i] < subcategories[j]
-		})
 		assert.Equal(t, inputCategory.Subcategories, subcategories)
 
 	})
+
+	t.Run("given subcategory insert fails when saveCategories called then error returned and no categories saved", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		var repo = CockroachDbCategoriesRepository{
+			Connection: conn,
+			UserId:     userId,
+			ProfileId:  profileId,
+		}
+
+		_, err := repo.SaveCategories(context.Background(), models.TemplateVersion{}, []models.Category{
+			{
+				CategoryName:    "valid category",
+				Subcategories:   []string{"sub1"},
+				TransactionType: "expense",
+			},
+			{
+				CategoryName:    "category with duplicate subcategories",
+				Subcategories:   []string{"duplicate", "duplicate"},
+				TransactionType: "expense",
+			},
+		})
+
+		var subcategoryInsertError *SubcategoryInsertError
+		assert.True(t, errors.As(err, &subcategoryInsertError))
+		assert.Equal(t, "category with duplicate subcategories", subcategoryInsertError.CategoryName)
+		assert.Equal(t, "duplicate", subcategoryInsertError.SubcategoryName)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM category`).Scan(&numberOfCategories)
+		assert.Equal(t, 0, numberOfCategories)
+	})
+
+	t.Run("given cancelled context when saveCategories called then context error returned and no categories saved", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		var repo = CockroachDbCategoriesRepository{
+			Connection: conn,
+			UserId:     userId,
+			ProfileId:  profileId,
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repo.SaveCategories(ctx, models.TemplateVersion{Name: "au-default", Version: 1}, []models.Category{
+			{
+				CategoryName:    "category",
+				Subcategories:   []string{"sub1"},
+				TransactionType: "expense",
+			},
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM category`).Scan(&numberOfCategories)
+		assert.Equal(t, 0, numberOfCategories)
+	})
+
+	t.Run("given context cancelled part way through when saveCategories called then transaction rolled back", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		// A query cancelled part way through closes its connection, so the repository gets its own
+		repoConn, _ := pgx.Connect(context.Background(), dsn)
+		var repo = CockroachDbCategoriesRepository{
+			Connection: repoConn,
+			UserId:     userId,
+			ProfileId:  profileId,
+		}
+
+		categories := make([]models.Category, 0, 2000)
+		for i := 0; i < 2000; i++ {
+			categories = append(categories, models.Category{
+				CategoryName:    fmt.Sprintf("category %d", i),
+				Subcategories:   []string{"sub1", "sub2"},
+				TransactionType: "expense",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := repo.SaveCategories(ctx, models.TemplateVersion{}, categories)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM category`).Scan(&numberOfCategories)
+		assert.Equal(t, 0, numberOfCategories)
+	})
+
+	t.Run("given existing categories when saveCategories called in merge mode then only missing items created", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		var repo = CockroachDbCategoriesRepository{
+			Connection: conn,
+			UserId:     userId,
+			ProfileId:  profileId,
+			Mode:       MergeMode,
+		}
+
+		_, err := repo.SaveCategories(context.Background(), models.TemplateVersion{}, []models.Category{
+			{
+				CategoryName:    "existing",
+				Subcategories:   []string{"sub1"},
+				TransactionType: "expense",
+			},
+		})
+		assert.Nil(t, err)
+
+		savedCategories, err := repo.SaveCategories(context.Background(), models.TemplateVersion{}, []models.Category{
+			{
+				CategoryName:    "existing",
+				Subcategories:   []string{"sub1", "sub2"},
+				TransactionType: "expense",
+			},
+			{
+				CategoryName:    "new",
+				Subcategories:   []string{"sub1"},
+				TransactionType: "income",
+			},
+		})
+		assert.Nil(t, err)
+
+		for _, savedCategory := range savedCategories {
+			assert.NotEmpty(t, savedCategory.Id)
+			for i, savedSubcategory := range savedCategory.Subcategories {
+				assert.NotEmpty(t, savedSubcategory.Id)
+				savedCategory.Subcategories[i].Id = ""
+			}
+		}
+		savedCategories[0].Id, savedCategories[1].Id = "", ""
+
+		assert.Equal(t, []models.SavedCategory{
+			{
+				CategoryName:    "existing",
+				TransactionType: "expense",
+				Created:         false,
+				Subcategories: []models.SavedSubcategory{
+					{SubcategoryName: "sub1", Created: false},
+					{SubcategoryName: "sub2", Created: true},
+				},
+			},
+			{
+				CategoryName:    "new",
+				TransactionType: "income",
+				Created:         true,
+				Subcategories: []models.SavedSubcategory{
+					{SubcategoryName: "sub1", Created: true},
+				},
+			},
+		}, savedCategories)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM category`).Scan(&numberOfCategories)
+		assert.Equal(t, 2, numberOfCategories)
+
+		var numberOfSubcategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM subcategory`).Scan(&numberOfSubcategories)
+		assert.Equal(t, 3, numberOfSubcategories)
+	})
+
+	t.Run("given unknown transaction type when saveCategories called in merge mode then CategoryInsertError names category and nothing saved", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		var repo = CockroachDbCategoriesRepository{
+			Connection: conn,
+			UserId:     userId,
+			ProfileId:  profileId,
+			Mode:       MergeMode,
+		}
+
+		_, err := repo.SaveCategories(context.Background(), models.TemplateVersion{}, []models.Category{
+			{
+				CategoryName:    "valid category",
+				Subcategories:   []string{"sub1"},
+				TransactionType: "expense",
+			},
+			{
+				CategoryName:    "invalid category",
+				Subcategories:   []string{"sub1"},
+				TransactionType: "transfer",
+			},
+		})
+
+		var categoryInsertError *CategoryInsertError
+		assert.True(t, errors.As(err, &categoryInsertError))
+		assert.Equal(t, "invalid category", categoryInsertError.CategoryName)
+		assert.Equal(t, "transfer", categoryInsertError.TransactionType)
+
+		var numberOfCategories int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM category`).Scan(&numberOfCategories)
+		assert.Equal(t, 0, numberOfCategories)
+	})
+
+	t.Run("given template version when saveCategories called then template recorded once for profile", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		var repo = CockroachDbCategoriesRepository{
+			Connection: conn,
+			UserId:     userId,
+			ProfileId:  profileId,
+			Mode:       MergeMode,
+		}
+
+		_, err := repo.SaveCategories(context.Background(), models.TemplateVersion{Name: "au-default", Version: 1}, []models.Category{})
+		assert.Nil(t, err)
+		_, err = repo.SaveCategories(context.Background(), models.TemplateVersion{Name: "minimal", Version: 1}, []models.Category{})
+		assert.Nil(t, err)
+
+		var templateName string
+		var templateVersion int
+		conn.QueryRow(context.Background(), `SELECT template_name, template_version FROM profilecategorytemplate WHERE profile_id = $1`, profileId).Scan(&templateName, &templateVersion)
+		assert.Equal(t, "au-default", templateName)
+		assert.Equal(t, 1, templateVersion)
+	})
+
+	t.Run("given UpdateTemplateVersion when saveCategories called then recorded template replaced", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		var repo = CockroachDbCategoriesRepository{
+			Connection: conn,
+			UserId:     userId,
+			ProfileId:  profileId,
+			Mode:       MergeMode,
+		}
+
+		_, err := repo.SaveCategories(context.Background(), models.TemplateVersion{Name: "au-default", Version: 1}, []models.Category{})
+		assert.Nil(t, err)
+
+		repo.UpdateTemplateVersion = true
+		_, err = repo.SaveCategories(context.Background(), models.TemplateVersion{Name: "au-default", Version: 2}, []models.Category{})
+		assert.Nil(t, err)
+
+		var templateVersion int
+		conn.QueryRow(context.Background(), `SELECT template_version FROM profilecategorytemplate WHERE profile_id = $1`, profileId).Scan(&templateVersion)
+		assert.Equal(t, 2, templateVersion)
+	})
 }
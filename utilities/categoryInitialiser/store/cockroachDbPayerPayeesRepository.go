@@ -0,0 +1,124 @@
+package store
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// customExternalLinkType is the external link type of payers and payees entered by hand rather than linked to a place
+const customExternalLinkType = "Custom"
+
+type payerPayeeKey struct {
+	Type string
+	Name string
+}
+
+type CockroachDbPayerPayeesRepository struct {
+	Connection DbConnection
+	UserId     string
+	ProfileId  string
+	// DryRun saves payers and payees exactly as a real save would but rolls the transaction back instead of committing it
+	DryRun bool
+}
+
+// SavePayerPayees should run on the transaction categories are saved in so that a profile ends up with both or neither.
+// Payers and payees are saved as Custom ones, without an external link.
+func (c *CockroachDbPayerPayeesRepository) SavePayerPayees(ctx context.Context, payerPayees []models.PayerPayeeDto) (savedPayerPayees []models.SavedPayerPayee, err error) {
+	if len(payerPayees) == 0 {
+		return []models.SavedPayerPayee{}, nil
+	}
+
+	err = ExecuteInTransaction(ctx, c.Connection, func(tx pgx.Tx) error {
+		payerPayeeIds, err := c.insertPayerPayees(ctx, tx, payerPayees)
+		if err != nil {
+			return err
+		}
+
+		savedPayerPayees = make([]models.SavedPayerPayee, 0, len(payerPayees))
+		for _, payerPayee := range payerPayees {
+			id, created := payerPayeeIds.take(payerPayeeKey{Type: payerPayee.Type, Name: payerPayee.Name})
+			savedPayerPayees = append(savedPayerPayees, models.SavedPayerPayee{
+				Id:      id,
+				Name:    payerPayee.Name,
+				Type:    payerPayee.Type,
+				Created: created,
+			})
+		}
+
+		if c.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	return savedPayerPayees, nil
+}
+
+// insertPayerPayees inserts every payer and payee with a single UNNEST statement and returns their ids keyed by type
+// and name, looking up the ids of those that already existed afterwards. Only payers and payees without an external
+// link are matched by name, which is what the partial unique index on (profile_id, payerPayeeType_id, name) covers.
+func (c *CockroachDbPayerPayeesRepository) insertPayerPayees(ctx context.Context, tx pgx.Tx, payerPayees []models.PayerPayeeDto) (savedIds[payerPayeeKey], error) {
+	ids := newSavedIds[payerPayeeKey]()
+
+	names := make([]string, 0, len(payerPayees))
+	types := make([]string, 0, len(payerPayees))
+	for _, payerPayee := range payerPayees {
+		names = append(names, payerPayee.Name)
+		types = append(types, payerPayee.Type)
+	}
+
+	rows, err := tx.Query(ctx,
+		`WITH input AS (
+			SELECT * FROM UNNEST($1::VARCHAR[], $2::STRING[]) AS input (name, payerpayee_type_name)
+		), inserted AS (
+			INSERT INTO payerpayee (name, user_id, profile_id, payerpayeetype_id, external_link_type_id, external_link_id)
+			SELECT input.name, $3::UUID, $4::UUID, ppt.id, ppelt.id, ''
+			FROM input
+			LEFT JOIN payerpayeetype ppt ON ppt.name = input.payerpayee_type_name
+			LEFT JOIN payerpayeeexternallinktype ppelt ON ppelt.name = $5
+			ON CONFLICT (profile_id, payerpayeetype_id, name) WHERE external_link_id = '' DO NOTHING
+			RETURNING id, name, payerpayeetype_id
+		)
+		SELECT inserted.id, inserted.name, ppt.name
+		FROM inserted
+		JOIN payerpayeetype ppt ON ppt.id = inserted.payerpayeetype_id`,
+		names, types, c.UserId, c.ProfileId, customExternalLinkType)
+	if err != nil {
+		return ids, err
+	}
+
+	var id string
+	var key payerPayeeKey
+	_, err = pgx.ForEachRow(rows, []any{&id, &key.Name, &key.Type}, func() error {
+		ids.created[key] = id
+		return nil
+	})
+	if err != nil || len(ids.created) == len(payerPayees) {
+		return ids, err
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT pp.id, pp.name, ppt.name
+		FROM payerpayee pp
+		JOIN payerpayeetype ppt ON ppt.id = pp.payerpayeetype_id
+		WHERE pp.profile_id = $1 AND pp.external_link_id = '' AND pp.name = ANY($2::VARCHAR[])`, c.ProfileId, names)
+	if err != nil {
+		return ids, err
+	}
+
+	_, err = pgx.ForEachRow(rows, []any{&id, &key.Name, &key.Type}, func() error {
+		if _, created := ids.created[key]; !created {
+			ids.existing[key] = id
+		}
+		return nil
+	})
+
+	return ids, err
+}
@@ -0,0 +1,107 @@
+//go:build integrationTest
+
+package store
+
+import (
+	"categoryInitialiser/models"
+	"categoryInitialiser/test_utils"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCockroachDbPayerPayeesRepository(t *testing.T) {
+	t.Run("given existing payee when SavePayerPayees called then only missing payers and payees created as Custom", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("test-user")
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+		cockroachDbHelpers.CreateUserProfile(userId, profileId)
+
+		var existingPayeeId string
+		conn.QueryRow(context.Background(),
+			`INSERT INTO payerpayee (name, user_id, profile_id, payerpayeetype_id, external_link_type_id, external_link_id)
+			SELECT 'Woolworths', $1, $2, ppt.id, ppelt.id, ''
+			FROM payerpayeetype ppt, payerpayeeexternallinktype ppelt
+			WHERE ppt.name = 'payee' AND ppelt.name = 'Custom'
+			RETURNING id`, userId, profileId).Scan(&existingPayeeId)
+
+		repo := CockroachDbPayerPayeesRepository{Connection: conn, UserId: userId, ProfileId: profileId}
+
+		savedPayerPayees, err := repo.SavePayerPayees(context.Background(), []models.PayerPayeeDto{
+			{Name: "Woolworths", Type: "payee"},
+			{Name: "Coles", Type: "payee"},
+			{Name: "Employer", Type: "payer"},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, savedPayerPayees, 3)
+		assert.Equal(t, existingPayeeId, savedPayerPayees[0].Id)
+		assert.False(t, savedPayerPayees[0].Created)
+		assert.True(t, savedPayerPayees[1].Created)
+		assert.True(t, savedPayerPayees[2].Created)
+
+		rows, _ := conn.Query(context.Background(),
+			`SELECT pp.name || ':' || ppt.name || ':' || ppelt.name
+			FROM payerpayee pp
+			JOIN payerpayeetype ppt ON ppt.id = pp.payerpayeetype_id
+			JOIN payerpayeeexternallinktype ppelt ON ppelt.id = pp.external_link_type_id
+			WHERE pp.profile_id = $1 ORDER BY pp.name`, profileId)
+		payerPayeeTypes, _ := pgx.CollectRows(rows, pgx.RowTo[string])
+		assert.Equal(t, []string{"Coles:payee:Custom", "Employer:payer:Custom", "Woolworths:payee:Custom"}, payerPayeeTypes)
+	})
+
+	t.Run("given payer with the same name as a payee when SavePayerPayees called then both created", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("test-user")
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		repo := CockroachDbPayerPayeesRepository{Connection: conn, UserId: userId, ProfileId: profileId}
+
+		savedPayerPayees, err := repo.SavePayerPayees(context.Background(), []models.PayerPayeeDto{
+			{Name: "Employer", Type: "payer"},
+			{Name: "Employer", Type: "payee"},
+		})
+		assert.Nil(t, err)
+		assert.True(t, savedPayerPayees[0].Created)
+		assert.True(t, savedPayerPayees[1].Created)
+		assert.NotEqual(t, savedPayerPayees[0].Id, savedPayerPayees[1].Id)
+	})
+
+	t.Run("given dry run when SavePayerPayees called then payers and payees reported as created but not saved", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("test-user")
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		repo := CockroachDbPayerPayeesRepository{Connection: conn, UserId: userId, ProfileId: profileId, DryRun: true}
+
+		savedPayerPayees, err := repo.SavePayerPayees(context.Background(), []models.PayerPayeeDto{{Name: "Coles", Type: "payee"}})
+		assert.Nil(t, err)
+		assert.True(t, savedPayerPayees[0].Created)
+
+		var numberOfPayerPayees int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM payerpayee`).Scan(&numberOfPayerPayees)
+		assert.Equal(t, 0, numberOfPayerPayees)
+	})
+}
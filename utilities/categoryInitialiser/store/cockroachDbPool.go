@@ -0,0 +1,33 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// A Lambda container is frozen between invocations, so connections can go stale without the background health
+	// check noticing. pgxpool also pings any connection that has been idle for over a second before handing it out.
+	poolHealthCheckPeriod     = 30 * time.Second
+	poolMaxConnectionIdleTime = 5 * time.Minute
+	poolMaxConnectionLifetime = 30 * time.Minute
+)
+
+// NewCockroachDbPool creates a connection pool that is meant to live for the lifetime of the Lambda container.
+// Connections are opened lazily, the first query made through the pool will surface any connection error.
+// poolSize is not defaulted here, callers pass the size resolved by the config package.
+func NewCockroachDbPool(connectionString string, poolSize int32) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig.MaxConns = poolSize
+	poolConfig.HealthCheckPeriod = poolHealthCheckPeriod
+	poolConfig.MaxConnIdleTime = poolMaxConnectionIdleTime
+	poolConfig.MaxConnLifetime = poolMaxConnectionLifetime
+
+	return pgxpool.NewWithConfig(context.Background(), poolConfig)
+}
@@ -0,0 +1,34 @@
+//go:build !integrationTest
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCockroachDbPool(t *testing.T) {
+	connectionString := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+
+	t.Run("given pool size, when NewCockroachDbPool called, then pool limited to pool size", func(t *testing.T) {
+		pool, err := NewCockroachDbPool(connectionString, 5)
+		assert.Nil(t, err)
+		defer pool.Close()
+
+		assert.Equal(t, int32(5), pool.Config().MaxConns)
+		assert.Equal(t, poolHealthCheckPeriod, pool.Config().HealthCheckPeriod)
+	})
+
+	t.Run("given no pool size, when NewCockroachDbPool called, then error returned", func(t *testing.T) {
+		_, err := NewCockroachDbPool(connectionString, 0)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("given invalid connection string, when NewCockroachDbPool called, then error returned", func(t *testing.T) {
+		_, err := NewCockroachDbPool("postgresql://root@localhost:not-a-port/moneymate_db_local", 1)
+
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const lockPollInterval = 250 * time.Millisecond
+
+// CockroachDbProfileLock is a lease based lock stored in the profileinitialisationlock table. A lease that has
+// expired, e.g. because the Lambda holding it was killed, can be taken over by the next caller.
+type CockroachDbProfileLock struct {
+	Connection    DbConnection
+	ProfileId     string
+	LeaseDuration time.Duration
+	WaitTimeout   time.Duration
+	lockId        string
+}
+
+// Acquire waits up to WaitTimeout for the lock and returns ErrProfileLocked if it is still held after that. It gives up
+// early with ctx's error if ctx is done first.
+func (l *CockroachDbProfileLock) Acquire(ctx context.Context) error {
+	deadline := time.Now().Add(l.WaitTimeout)
+
+	for {
+		acquired, err := l.tryAcquire(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrProfileLocked
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (l *CockroachDbProfileLock) tryAcquire(ctx context.Context) (bool, error) {
+	err := l.Connection.QueryRow(ctx,
+		`INSERT INTO profileinitialisationlock (profile_id, lock_id, expires_at)
+		VALUES ($1, gen_random_uuid(), now() + $2 * INTERVAL '1 millisecond')
+		ON CONFLICT (profile_id) DO UPDATE SET lock_id = excluded.lock_id, expires_at = excluded.expires_at
+		WHERE profileinitialisationlock.expires_at < now()
+		RETURNING lock_id`, l.ProfileId, l.LeaseDuration.Milliseconds(),
+	).Scan(&l.lockId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Release deletes the lease. It still runs when ctx has been cancelled so an abandoned initialisation doesn't hold the
+// lock until the lease expires.
+func (l *CockroachDbProfileLock) Release(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	_, err := l.Connection.Exec(ctx,
+		`DELETE FROM profileinitialisationlock WHERE profile_id = $1 AND lock_id = $2`, l.ProfileId, l.lockId)
+	return err
+}
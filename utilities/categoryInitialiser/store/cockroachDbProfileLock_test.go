@@ -0,0 +1,121 @@
+//go:build integrationTest
+
+package store
+
+import (
+	"categoryInitialiser/test_utils"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCockroachDbProfileLock(t *testing.T) {
+	t.Run("given lock held by another caller when Acquire called then ErrProfileLocked returned", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		firstLock := CockroachDbProfileLock{
+			Connection:    conn,
+			ProfileId:     profileId,
+			LeaseDuration: time.Minute,
+		}
+		secondLock := CockroachDbProfileLock{
+			Connection:    conn,
+			ProfileId:     profileId,
+			LeaseDuration: time.Minute,
+			WaitTimeout:   500 * time.Millisecond,
+		}
+
+		assert.Nil(t, firstLock.Acquire(context.Background()))
+		assert.Equal(t, ErrProfileLocked, secondLock.Acquire(context.Background()))
+
+		assert.Nil(t, firstLock.Release(context.Background()))
+		assert.Nil(t, secondLock.Acquire(context.Background()))
+		assert.Nil(t, secondLock.Release(context.Background()))
+	})
+
+	t.Run("given lock held and context cancelled while waiting when Acquire called then context error returned before wait timeout", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		firstLock := CockroachDbProfileLock{
+			Connection:    conn,
+			ProfileId:     profileId,
+			LeaseDuration: time.Minute,
+		}
+		secondLock := CockroachDbProfileLock{
+			Connection:    conn,
+			ProfileId:     profileId,
+			LeaseDuration: time.Minute,
+			WaitTimeout:   10 * time.Second,
+		}
+
+		assert.Nil(t, firstLock.Acquire(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		startTime := time.Now()
+		assert.ErrorIs(t, secondLock.Acquire(ctx), context.DeadlineExceeded)
+		assert.Less(t, time.Since(startTime), 5*time.Second)
+
+		cancelledCtx, cancelReleaseCtx := context.WithCancel(context.Background())
+		cancelReleaseCtx()
+		assert.Nil(t, firstLock.Release(cancelledCtx))
+
+		var numberOfLocks int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM profileinitialisationlock`).Scan(&numberOfLocks)
+		assert.Equal(t, 0, numberOfLocks)
+	})
+
+	t.Run("given lock lease expired when Acquire called then lock taken over", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		expiredLock := CockroachDbProfileLock{
+			Connection:    conn,
+			ProfileId:     profileId,
+			LeaseDuration: 100 * time.Millisecond,
+		}
+		newLock := CockroachDbProfileLock{
+			Connection:    conn,
+			ProfileId:     profileId,
+			LeaseDuration: time.Minute,
+			WaitTimeout:   time.Second,
+		}
+
+		assert.Nil(t, expiredLock.Acquire(context.Background()))
+		time.Sleep(200 * time.Millisecond)
+
+		assert.Nil(t, newLock.Acquire(context.Background()))
+
+		assert.Nil(t, expiredLock.Release(context.Background()))
+		var numberOfLocks int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM profileinitialisationlock`).Scan(&numberOfLocks)
+		assert.Equal(t, 1, numberOfLocks)
+	})
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"categoryInitialiser/models"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CockroachDbProfilesRepository struct {
+	Connection DbConnection
+}
+
+// ListProfiles only returns profiles that at least one user belongs to, since categories can't be saved without one
+func (c *CockroachDbProfilesRepository) ListProfiles(ctx context.Context, filter ProfileFilter, afterProfileId string, limit int) ([]models.Profile, error) {
+	profileIds := filter.ProfileIds
+	if profileIds == nil {
+		profileIds = []string{}
+	}
+
+	rows, err := c.Connection.Query(ctx,
+		`SELECT p.id, up.user_id, COALESCE(pct.template_name, ''), COALESCE(pct.template_version, 0)
+		FROM profile p
+		JOIN LATERAL (SELECT user_id FROM userprofile WHERE profile_id = p.id ORDER BY user_id LIMIT 1) up ON true
+		LEFT JOIN profilecategorytemplate pct ON pct.profile_id = p.id
+		WHERE ($1::UUID IS NULL OR p.id > $1::UUID)
+		AND (cardinality($2::UUID[]) = 0 OR p.id = ANY($2::UUID[]))
+		AND ($3::UUID IS NULL OR EXISTS (SELECT 1 FROM userprofile WHERE profile_id = p.id AND user_id = $3::UUID))
+		ORDER BY p.id
+		LIMIT $4`, nullIfEmpty(afterProfileId), profileIds, nullIfEmpty(filter.UserId), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanProfile)
+}
+
+// ListUninitialisedProfiles finds profiles left without categories, e.g. because the Auth0 hook failed or the profile
+// was created before the initialiser was wired up. Like ListProfiles it skips profiles that no user belongs to, and it
+// skips profiles with a recorded template since those were initialised and their users removed the categories.
+func (c *CockroachDbProfilesRepository) ListUninitialisedProfiles(ctx context.Context) ([]models.Profile, error) {
+	rows, err := c.Connection.Query(ctx,
+		`SELECT p.id, up.user_id, COALESCE(pct.template_name, ''), COALESCE(pct.template_version, 0)
+		FROM profile p
+		JOIN LATERAL (SELECT user_id FROM userprofile WHERE profile_id = p.id ORDER BY user_id LIMIT 1) up ON true
+		LEFT JOIN profilecategorytemplate pct ON pct.profile_id = p.id
+		LEFT JOIN category c ON c.profile_id = p.id
+		WHERE c.id IS NULL AND pct.profile_id IS NULL
+		ORDER BY p.id`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanProfile)
+}
+
+func scanProfile(row pgx.CollectableRow) (models.Profile, error) {
+	var profile models.Profile
+	err := row.Scan(&profile.ProfileId, &profile.UserId, &profile.TemplateVersion.Name, &profile.TemplateVersion.Version)
+	return profile, err
+}
+
+func nullIfEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
@@ -0,0 +1,106 @@
+//go:build integrationTest
+
+package store
+
+import (
+	"categoryInitialiser/models"
+	"categoryInitialiser/test_utils"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCockroachDbProfilesRepository(t *testing.T) {
+	t.Run("given profiles with and without users when ListProfiles called then pages of profiles with users returned in id order", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+		for _, profileName := range []string{"Profile 1", "Profile 2", "Profile 3"} {
+			profileId, _ := cockroachDbHelpers.CreateProfile(profileName)
+			cockroachDbHelpers.CreateUserProfile(userId, profileId)
+		}
+		cockroachDbHelpers.CreateProfile("Profile without users")
+
+		repo := CockroachDbProfilesRepository{Connection: conn}
+
+		firstPage, err := repo.ListProfiles(context.Background(), ProfileFilter{}, "", 2)
+		assert.Nil(t, err)
+		assert.Len(t, firstPage, 2)
+		assert.Less(t, firstPage[0].ProfileId, firstPage[1].ProfileId)
+		assert.Equal(t, userId, firstPage[0].UserId)
+
+		secondPage, err := repo.ListProfiles(context.Background(), ProfileFilter{}, firstPage[1].ProfileId, 2)
+		assert.Nil(t, err)
+		assert.Len(t, secondPage, 1)
+		assert.Less(t, firstPage[1].ProfileId, secondPage[0].ProfileId)
+	})
+
+	t.Run("given filter and recorded template when ListProfiles called then matching profiles returned with their template", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+		otherUserId, _ := cockroachDbHelpers.CreateUser("golang_test_other")
+		profileId, _ := cockroachDbHelpers.CreateProfile("Profile")
+		otherProfileId, _ := cockroachDbHelpers.CreateProfile("Other profile")
+		cockroachDbHelpers.CreateUserProfile(userId, profileId)
+		cockroachDbHelpers.CreateUserProfile(otherUserId, otherProfileId)
+		conn.Exec(context.Background(), `INSERT INTO profilecategorytemplate (profile_id, template_name, template_version) VALUES ($1, 'au-default', 1)`, profileId)
+
+		repo := CockroachDbProfilesRepository{Connection: conn}
+
+		profiles, err := repo.ListProfiles(context.Background(), ProfileFilter{UserId: userId}, "", 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []models.Profile{
+			{ProfileId: profileId, UserId: userId, TemplateVersion: models.TemplateVersion{Name: "au-default", Version: 1}},
+		}, profiles)
+
+		profiles, err = repo.ListProfiles(context.Background(), ProfileFilter{ProfileIds: []string{otherProfileId}}, "", 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []models.Profile{{ProfileId: otherProfileId, UserId: otherUserId}}, profiles)
+	})
+
+	t.Run("given profiles with and without categories when ListUninitialisedProfiles called then only profiles never initialised returned", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		userId, _ := cockroachDbHelpers.CreateUser("golang_test")
+		initialisedProfileId, _ := cockroachDbHelpers.CreateProfile("Initialised profile")
+		uninitialisedProfileId, _ := cockroachDbHelpers.CreateProfile("Uninitialised profile")
+		cockroachDbHelpers.CreateUserProfile(userId, initialisedProfileId)
+		cockroachDbHelpers.CreateUserProfile(userId, uninitialisedProfileId)
+		emptiedProfileId, _ := cockroachDbHelpers.CreateProfile("Profile whose categories were all deleted")
+		cockroachDbHelpers.CreateUserProfile(userId, emptiedProfileId)
+		conn.Exec(context.Background(), `INSERT INTO profilecategorytemplate (profile_id, template_name, template_version) VALUES ($1, 'au-default', 1)`, emptiedProfileId)
+
+		categoriesRepository := CockroachDbCategoriesRepository{Connection: conn, UserId: userId, ProfileId: initialisedProfileId}
+		_, err := categoriesRepository.SaveCategories(context.Background(), models.TemplateVersion{}, []models.Category{
+			{CategoryName: "Category1", TransactionType: "expense", Subcategories: []string{"Subcategory1"}},
+		})
+		assert.Nil(t, err)
+
+		repo := CockroachDbProfilesRepository{Connection: conn}
+
+		profiles, err := repo.ListUninitialisedProfiles(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, []models.Profile{{ProfileId: uninitialisedProfileId, UserId: userId}}, profiles)
+	})
+}
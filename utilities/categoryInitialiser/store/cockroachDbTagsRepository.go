@@ -0,0 +1,86 @@
+package store
+
+import (
+	"categoryInitialiser/models"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CockroachDbTagsRepository struct {
+	Connection DbConnection
+	ProfileId  string
+	// DryRun saves tags exactly as a real save would but rolls the transaction back instead of committing it
+	DryRun bool
+}
+
+// SaveTags should run on the transaction categories are saved in so that a profile ends up with both or neither
+func (c *CockroachDbTagsRepository) SaveTags(ctx context.Context, tags []string) (savedTags []models.SavedTag, err error) {
+	if len(tags) == 0 {
+		return []models.SavedTag{}, nil
+	}
+
+	err = ExecuteInTransaction(ctx, c.Connection, func(tx pgx.Tx) error {
+		tagIds, err := c.insertTags(ctx, tx, tags)
+		if err != nil {
+			return err
+		}
+
+		savedTags = make([]models.SavedTag, 0, len(tags))
+		for _, tag := range tags {
+			id, created := tagIds.take(tag)
+			savedTags = append(savedTags, models.SavedTag{Id: id, Name: tag, Created: created})
+		}
+
+		if c.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	return savedTags, nil
+}
+
+// insertTags inserts every tag with a single UNNEST statement and returns their ids keyed by name, looking up the ids
+// of tags that already existed afterwards
+func (c *CockroachDbTagsRepository) insertTags(ctx context.Context, tx pgx.Tx, tags []string) (savedIds[string], error) {
+	ids := newSavedIds[string]()
+
+	rows, err := tx.Query(ctx,
+		`INSERT INTO tag (name, profile_id)
+		SELECT input.name, $2::UUID FROM UNNEST($1::STRING[]) AS input (name)
+		ON CONFLICT (name, profile_id) DO NOTHING
+		RETURNING id, name`, tags, c.ProfileId)
+	if err != nil {
+		return ids, err
+	}
+
+	var id, name string
+	_, err = pgx.ForEachRow(rows, []any{&id, &name}, func() error {
+		ids.created[name] = id
+		return nil
+	})
+	if err != nil || len(ids.created) == len(tags) {
+		return ids, err
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT id, name FROM tag WHERE profile_id = $1 AND name = ANY($2::STRING[])`, c.ProfileId, tags)
+	if err != nil {
+		return ids, err
+	}
+
+	_, err = pgx.ForEachRow(rows, []any{&id, &name}, func() error {
+		if _, created := ids.created[name]; !created {
+			ids.existing[name] = id
+		}
+		return nil
+	})
+
+	return ids, err
+}
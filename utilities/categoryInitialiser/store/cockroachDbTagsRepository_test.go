@@ -0,0 +1,64 @@
+//go:build integrationTest
+
+package store
+
+import (
+	"categoryInitialiser/test_utils"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCockroachDbTagsRepository(t *testing.T) {
+	t.Run("given existing tag when SaveTags called then only missing tags created", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+		var existingTagId string
+		conn.QueryRow(context.Background(), `INSERT INTO tag (name, profile_id) VALUES ('Holiday', $1) RETURNING id`, profileId).Scan(&existingTagId)
+
+		repo := CockroachDbTagsRepository{Connection: conn, ProfileId: profileId}
+
+		savedTags, err := repo.SaveTags(context.Background(), []string{"Holiday", "Business"})
+		assert.Nil(t, err)
+		assert.Len(t, savedTags, 2)
+		assert.Equal(t, existingTagId, savedTags[0].Id)
+		assert.False(t, savedTags[0].Created)
+		assert.Equal(t, "Business", savedTags[1].Name)
+		assert.True(t, savedTags[1].Created)
+
+		var numberOfTags int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM tag WHERE profile_id = $1`, profileId).Scan(&numberOfTags)
+		assert.Equal(t, 2, numberOfTags)
+	})
+
+	t.Run("given dry run when SaveTags called then tags reported as created but not saved", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		profileId, _ := cockroachDbHelpers.CreateProfile("Default Profile")
+
+		repo := CockroachDbTagsRepository{Connection: conn, ProfileId: profileId, DryRun: true}
+
+		savedTags, err := repo.SaveTags(context.Background(), []string{"Holiday"})
+		assert.Nil(t, err)
+		assert.True(t, savedTags[0].Created)
+
+		var numberOfTags int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM tag`).Scan(&numberOfTags)
+		assert.Equal(t, 0, numberOfTags)
+	})
+}
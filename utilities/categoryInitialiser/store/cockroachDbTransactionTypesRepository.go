@@ -0,0 +1,20 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CockroachDbTransactionTypesRepository struct {
+	Connection DbConnection
+}
+
+func (c *CockroachDbTransactionTypesRepository) GetTransactionTypes(ctx context.Context) ([]string, error) {
+	rows, err := c.Connection.Query(ctx, `SELECT name FROM transactiontype`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
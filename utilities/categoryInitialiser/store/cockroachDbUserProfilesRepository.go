@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CockroachDbUserProfilesRepository struct {
+	Connection DbConnection
+}
+
+func (c *CockroachDbUserProfilesRepository) GetOrCreateUserWithDefaultProfile(ctx context.Context, userIdentifier string) (userId string, profileId string, err error) {
+	err = ExecuteInTransaction(ctx, c.Connection, func(tx pgx.Tx) error {
+		userId, err = c.getOrCreateUser(ctx, tx, userIdentifier)
+		if err != nil {
+			return err
+		}
+
+		profileId, err = c.getOrCreateDefaultProfile(ctx, tx, userId)
+		return err
+	})
+
+	return
+}
+
+func (c *CockroachDbUserProfilesRepository) GetUserIdForProfile(ctx context.Context, userIdentifier string, profileId string) (userId string, err error) {
+	err = c.Connection.QueryRow(ctx,
+		`SELECT u.id
+		FROM users u
+		JOIN userprofile up ON up.user_id = u.id
+		WHERE u.user_identifier = $1 AND up.profile_id = $2`, userIdentifier, profileId,
+	).Scan(&userId)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrProfileNotAccessible
+	}
+
+	return userId, err
+}
+
+func (c *CockroachDbUserProfilesRepository) getOrCreateUser(ctx context.Context, tx pgx.Tx, userIdentifier string) (userId string, err error) {
+	err = tx.QueryRow(ctx,
+		`INSERT INTO users (user_identifier) VALUES ($1)
+		ON CONFLICT (user_identifier) DO NOTHING
+		RETURNING id`, userIdentifier,
+	).Scan(&userId)
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return userId, err
+	}
+
+	err = tx.QueryRow(ctx, `SELECT id FROM users WHERE user_identifier = $1`, userIdentifier).Scan(&userId)
+	return userId, err
+}
+
+func (c *CockroachDbUserProfilesRepository) getOrCreateDefaultProfile(ctx context.Context, tx pgx.Tx, userId string) (profileId string, err error) {
+	err = tx.QueryRow(ctx,
+		`SELECT p.id
+		FROM profile p
+		JOIN userprofile up ON up.profile_id = p.id
+		WHERE up.user_id = $1 AND p.display_name = $2
+		LIMIT 1`, userId, DefaultProfileName,
+	).Scan(&profileId)
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return profileId, err
+	}
+
+	err = tx.QueryRow(ctx, `INSERT INTO profile (display_name) VALUES ($1) RETURNING id`, DefaultProfileName).Scan(&profileId)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO userprofile (user_id, profile_id) VALUES ($1, $2)`, userId, profileId)
+	return profileId, err
+}
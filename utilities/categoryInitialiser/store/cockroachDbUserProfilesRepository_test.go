@@ -0,0 +1,91 @@
+//go:build integrationTest
+
+package store
+
+import (
+	"categoryInitialiser/test_utils"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCockroachDbUserProfilesRepository(t *testing.T) {
+	t.Run("given new user identifier when GetOrCreateUserWithDefaultProfile called then user and linked default profile created", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		repo := CockroachDbUserProfilesRepository{Connection: conn}
+
+		userId, profileId, err := repo.GetOrCreateUserWithDefaultProfile(context.Background(), "auth0|golang_test")
+		assert.Nil(t, err)
+
+		var userIdentifier string
+		conn.QueryRow(context.Background(), `SELECT user_identifier FROM users WHERE id = $1`, userId).Scan(&userIdentifier)
+		assert.Equal(t, "auth0|golang_test", userIdentifier)
+
+		var profileName string
+		conn.QueryRow(context.Background(),
+			`SELECT p.display_name FROM profile p JOIN userprofile up ON up.profile_id = p.id WHERE up.user_id = $1 AND p.id = $2`, userId, profileId,
+		).Scan(&profileName)
+		assert.Equal(t, DefaultProfileName, profileName)
+	})
+
+	t.Run("given existing user and default profile when GetOrCreateUserWithDefaultProfile called then existing ids returned", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		existingUserId, _ := cockroachDbHelpers.CreateUser("auth0|golang_test")
+		existingProfileId, _ := cockroachDbHelpers.CreateProfile(DefaultProfileName)
+		cockroachDbHelpers.CreateUserProfile(existingUserId, existingProfileId)
+
+		repo := CockroachDbUserProfilesRepository{Connection: conn}
+
+		userId, profileId, err := repo.GetOrCreateUserWithDefaultProfile(context.Background(), "auth0|golang_test")
+		assert.Nil(t, err)
+		assert.Equal(t, existingUserId, userId)
+		assert.Equal(t, existingProfileId, profileId)
+
+		var numberOfProfiles int
+		conn.QueryRow(context.Background(), `SELECT COUNT(1) FROM profile`).Scan(&numberOfProfiles)
+		assert.Equal(t, 1, numberOfProfiles)
+	})
+
+	t.Run("given user identifier and profile when GetUserIdForProfile called then user id returned only if they belong to the profile", func(t *testing.T) {
+		dsn := "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+		conn, _ := pgx.Connect(context.Background(), dsn)
+		cockroachDbHelpers := &test_utils.CockroachDbHelpers{
+			Connection: conn,
+		}
+
+		cockroachDbHelpers.ClearData()
+
+		existingUserId, _ := cockroachDbHelpers.CreateUser("auth0|golang_test")
+		existingProfileId, _ := cockroachDbHelpers.CreateProfile(DefaultProfileName)
+		cockroachDbHelpers.CreateUserProfile(existingUserId, existingProfileId)
+		otherProfileId, _ := cockroachDbHelpers.CreateProfile("Other Profile")
+
+		repo := CockroachDbUserProfilesRepository{Connection: conn}
+
+		userId, err := repo.GetUserIdForProfile(context.Background(), "auth0|golang_test", existingProfileId)
+		assert.Nil(t, err)
+		assert.Equal(t, existingUserId, userId)
+
+		_, err = repo.GetUserIdForProfile(context.Background(), "auth0|golang_test", otherProfileId)
+		assert.ErrorIs(t, err, ErrProfileNotAccessible)
+
+		_, err = repo.GetUserIdForProfile(context.Background(), "auth0|someone_else", existingProfileId)
+		assert.ErrorIs(t, err, ErrProfileNotAccessible)
+	})
+}
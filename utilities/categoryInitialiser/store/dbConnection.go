@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DbConnection is satisfied by a *pgx.Conn, a *pgxpool.Pool and a pgx.Tx, so repositories can run against the Lambda's
+// connection pool or take part in a wider transaction
+type DbConnection interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+var (
+	_ DbConnection = (*pgx.Conn)(nil)
+	_ DbConnection = (*pgxpool.Pool)(nil)
+	_ DbConnection = (pgx.Tx)(nil)
+)
@@ -0,0 +1,31 @@
+package store
+
+import "fmt"
+
+type CategoryInsertError struct {
+	CategoryName    string
+	TransactionType string
+	Err             error
+}
+
+func (e *CategoryInsertError) Error() string {
+	return fmt.Sprintf("failed to insert %s category %q: %v", e.TransactionType, e.CategoryName, e.Err)
+}
+
+func (e *CategoryInsertError) Unwrap() error {
+	return e.Err
+}
+
+type SubcategoryInsertError struct {
+	CategoryName    string
+	SubcategoryName string
+	Err             error
+}
+
+func (e *SubcategoryInsertError) Error() string {
+	return fmt.Sprintf("failed to insert subcategory %q under category %q: %v", e.SubcategoryName, e.CategoryName, e.Err)
+}
+
+func (e *SubcategoryInsertError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,11 @@
+package store
+
+import (
+	"categoryInitialiser/models"
+	"context"
+)
+
+type PayerPayeesRepository interface {
+	// SavePayerPayees creates the custom payers and payees the profile doesn't have yet and leaves existing ones alone
+	SavePayerPayees(context.Context, []models.PayerPayeeDto) ([]models.SavedPayerPayee, error)
+}
@@ -0,0 +1,13 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrProfileLocked = errors.New("profile is locked by another initialisation")
+
+type ProfileLock interface {
+	Acquire(ctx context.Context) error
+	Release(ctx context.Context) error
+}
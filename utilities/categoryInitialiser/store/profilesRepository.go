@@ -0,0 +1,19 @@
+package store
+
+import (
+	"categoryInitialiser/models"
+	"context"
+)
+
+// ProfileFilter narrows the profiles listed, empty fields match every profile
+type ProfileFilter struct {
+	ProfileIds []string
+	UserId     string
+}
+
+type ProfilesRepository interface {
+	// ListProfiles returns up to limit profiles matching filter with an id greater than afterProfileId, ordered by id
+	ListProfiles(ctx context.Context, filter ProfileFilter, afterProfileId string, limit int) ([]models.Profile, error)
+	// ListUninitialisedProfiles returns the profiles that don't have any categories, ordered by id
+	ListUninitialisedProfiles(ctx context.Context) ([]models.Profile, error)
+}
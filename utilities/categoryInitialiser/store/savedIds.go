@@ -0,0 +1,35 @@
+package store
+
+// savedIds holds the ids of rows saved by a batch, split into those the batch created and those that already existed
+type savedIds[K comparable] struct {
+	created  map[K]string
+	existing map[K]string
+}
+
+func newSavedIds[K comparable]() savedIds[K] {
+	return savedIds[K]{
+		created:  make(map[K]string),
+		existing: make(map[K]string),
+	}
+}
+
+func (ids savedIds[K]) get(key K) (string, bool) {
+	if id, ok := ids.created[key]; ok {
+		return id, true
+	}
+
+	id, ok := ids.existing[key]
+	return id, ok
+}
+
+// take returns the id saved for key and whether it was created. A created id is moved to existing so that a key
+// asked for again is reported as already existing.
+func (ids savedIds[K]) take(key K) (id string, created bool) {
+	if id, ok := ids.created[key]; ok {
+		delete(ids.created, key)
+		ids.existing[key] = id
+		return id, true
+	}
+
+	return ids.existing[key], false
+}
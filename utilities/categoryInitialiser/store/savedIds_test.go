@@ -0,0 +1,67 @@
+//go:build !integrationTest
+
+package store
+
+import (
+	"categoryInitialiser/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSavedCategories(t *testing.T) {
+	t.Run("given created and existing ids, when buildSavedCategories called, then categories laid out in input order", func(t *testing.T) {
+		categoryIds := newSavedIds[categoryKey]()
+		categoryIds.existing[categoryKey{TransactionType: "expense", Name: "existing"}] = "category-1"
+		categoryIds.created[categoryKey{TransactionType: "income", Name: "new"}] = "category-2"
+
+		subcategoryIds := newSavedIds[subcategoryKey]()
+		subcategoryIds.existing[subcategoryKey{CategoryId: "category-1", Name: "sub1"}] = "subcategory-1"
+		subcategoryIds.created[subcategoryKey{CategoryId: "category-1", Name: "sub2"}] = "subcategory-2"
+		subcategoryIds.created[subcategoryKey{CategoryId: "category-2", Name: "sub1"}] = "subcategory-3"
+
+		savedCategories := buildSavedCategories([]models.Category{
+			{CategoryName: "existing", TransactionType: "expense", Subcategories: []string{"sub1", "sub2"}},
+			{CategoryName: "new", TransactionType: "income", Subcategories: []string{"sub1"}},
+		}, categoryIds, subcategoryIds)
+
+		assert.Equal(t, []models.SavedCategory{
+			{
+				Id:              "category-1",
+				CategoryName:    "existing",
+				TransactionType: "expense",
+				Created:         false,
+				Subcategories: []models.SavedSubcategory{
+					{Id: "subcategory-1", SubcategoryName: "sub1", Created: false},
+					{Id: "subcategory-2", SubcategoryName: "sub2", Created: true},
+				},
+			},
+			{
+				Id:              "category-2",
+				CategoryName:    "new",
+				TransactionType: "income",
+				Created:         true,
+				Subcategories: []models.SavedSubcategory{
+					{Id: "subcategory-3", SubcategoryName: "sub1", Created: true},
+				},
+			},
+		}, savedCategories)
+	})
+
+	t.Run("given subcategory listed twice, when buildSavedCategories called, then only first occurrence created", func(t *testing.T) {
+		categoryIds := newSavedIds[categoryKey]()
+		categoryIds.created[categoryKey{TransactionType: "expense", Name: "category"}] = "category-1"
+
+		subcategoryIds := newSavedIds[subcategoryKey]()
+		subcategoryIds.created[subcategoryKey{CategoryId: "category-1", Name: "duplicate"}] = "subcategory-1"
+
+		savedCategories := buildSavedCategories([]models.Category{
+			{CategoryName: "category", TransactionType: "expense", Subcategories: []string{"duplicate", "duplicate"}},
+		}, categoryIds, subcategoryIds)
+
+		assert.Equal(t, []models.SavedSubcategory{
+			{Id: "subcategory-1", SubcategoryName: "duplicate", Created: true},
+			{Id: "subcategory-1", SubcategoryName: "duplicate", Created: false},
+		}, savedCategories[0].Subcategories)
+	})
+}
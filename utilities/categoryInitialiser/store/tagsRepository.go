@@ -0,0 +1,11 @@
+package store
+
+import (
+	"categoryInitialiser/models"
+	"context"
+)
+
+type TagsRepository interface {
+	// SaveTags creates the tags the profile doesn't have yet and leaves existing ones alone
+	SaveTags(context.Context, []string) ([]models.SavedTag, error)
+}
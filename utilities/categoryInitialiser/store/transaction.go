@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	serializationFailureCode = "40001"
+	// integrityConstraintViolationClass is the SQLSTATE class of constraint violations
+	integrityConstraintViolationClass = "23"
+	maxTransactionAttempts            = 5
+	transactionRetryBackoff           = 50 * time.Millisecond
+	// rollbackTimeout bounds the rollback, which still runs after ctx has been cancelled
+	rollbackTimeout = 5 * time.Second
+)
+
+// ExecuteInTransaction runs fn inside a single database transaction, committing if fn succeeds and rolling
+// back otherwise. CockroachDB serialization failures (SQLSTATE 40001) cause the whole transaction to be retried
+// until ctx is done. If connection is already a transaction, fn runs in a savepoint and retrying is left to the
+// outer transaction.
+func ExecuteInTransaction(ctx context.Context, connection DbConnection, fn func(tx pgx.Tx) error) (err error) {
+	if _, nested := connection.(pgx.Tx); nested {
+		return runInTransaction(ctx, connection, fn)
+	}
+
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		err = runInTransaction(ctx, connection, fn)
+		if !isSerializationFailure(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * transactionRetryBackoff):
+		}
+	}
+
+	return err
+}
+
+// runInTransaction is pgx.BeginFunc except that the rollback is not bound to ctx, so a transaction abandoned because
+// ctx was cancelled is still rolled back cleanly rather than left for the server to clean up
+func runInTransaction(ctx context.Context, connection DbConnection, fn func(tx pgx.Tx) error) (err error) {
+	tx, err := connection.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+		defer cancel()
+
+		rollbackErr := tx.Rollback(rollbackCtx)
+		if err == nil && rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			err = rollbackErr
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == serializationFailureCode
+}
+
+// isIntegrityConstraintViolation reports whether err is a not null, foreign key, unique or check constraint violation
+func isIntegrityConstraintViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, integrityConstraintViolationClass)
+}
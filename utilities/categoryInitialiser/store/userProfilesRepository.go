@@ -0,0 +1,17 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+const DefaultProfileName = "Default Profile"
+
+var ErrProfileNotAccessible = errors.New("user does not exist or does not belong to the profile")
+
+type UserProfilesRepository interface {
+	// GetOrCreateUserWithDefaultProfile returns the user with userIdentifier and their default profile, creating either if missing
+	GetOrCreateUserWithDefaultProfile(ctx context.Context, userIdentifier string) (userId string, profileId string, err error)
+	// GetUserIdForProfile returns the id of the user with userIdentifier, or ErrProfileNotAccessible unless they belong to profileId
+	GetUserIdForProfile(ctx context.Context, userIdentifier string, profileId string) (userId string, err error)
+}
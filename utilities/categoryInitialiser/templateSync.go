@@ -0,0 +1,175 @@
+package main
+
+import (
+	"categoryInitialiser/category_provider"
+	"categoryInitialiser/models"
+	"categoryInitialiser/store"
+	"categoryInitialiser/template_sync"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// runSync adds the entries of a template that existing profiles are missing. It exits with 1 if any profile failed or
+// the sync was interrupted, which can then be resumed by running it again with the same -state-file.
+func runSync(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	template := flags.String("template", "", "template pack to sync to as name or name@version, defaults to the configured default template")
+	baselineTemplate := flags.String("baseline", "", "template to compare profiles without a recorded template against, without one those profiles are skipped")
+	profileIds := flags.String("profile-id", "", "comma separated ids of the profiles to sync instead of every profile")
+	userId := flags.String("user-id", "", "only sync profiles the user belongs to")
+	rate := flags.Float64("rate", 5, "maximum profiles synced per second, 0 for no limit")
+	batchSize := flags.Int("batch-size", 100, "number of profiles read at a time")
+	stateFile := flags.String("state-file", "", "file to record progress in, an interrupted sync resumes from it")
+	dryRun := flags.Bool("dry-run", false, "report what would be added without writing it")
+	format := flags.String("format", textFormat, "output format, text or json")
+	databaseUrl := flags.String("database-url", "", "CockroachDB connection string, overrides the configured connection string")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *format != textFormat && *format != jsonFormat {
+		fmt.Fprintf(stderr, "unknown format %q, expected %s or %s\n", *format, textFormat, jsonFormat)
+		return 2
+	}
+	if *rate < 0 {
+		fmt.Fprintln(stderr, "-rate can't be negative")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := loadCommandConfig(ctx, *databaseUrl)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	templatePacks, err := category_provider.LoadEmbeddedTemplatePacks()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load template packs: %v\n", err)
+		return 1
+	}
+
+	if *template == "" {
+		*template = cfg.DefaultTemplate
+	}
+	target, err := templatePacks.Get(*template)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+
+	var baseline *category_provider.TemplatePack
+	if *baselineTemplate != "" {
+		baselinePack, err := templatePacks.Get(*baselineTemplate)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return 1
+		}
+		baseline = &baselinePack
+	}
+
+	connection, err := pgx.Connect(ctx, cfg.CockroachDbConnectionString)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer connection.Close(context.Background())
+
+	syncer := &template_sync.Syncer{
+		Profiles:  &store.CockroachDbProfilesRepository{Connection: connection},
+		Templates: templatePacks,
+		Target:    target,
+		Baseline:  baseline,
+		NewRepositories: func(profile models.Profile) (store.CategoriesRepository, store.ProfileLock) {
+			categoriesRepository := &store.CockroachDbCategoriesRepository{
+				Connection:            connection,
+				UserId:                profile.UserId,
+				ProfileId:             profile.ProfileId,
+				Mode:                  store.MergeMode,
+				DryRun:                *dryRun,
+				UpdateTemplateVersion: true,
+			}
+			profileLock := &store.CockroachDbProfileLock{
+				Connection:    connection,
+				ProfileId:     profile.ProfileId,
+				LeaseDuration: cfg.ProfileLockLeaseDuration,
+				WaitTimeout:   cfg.ProfileLockWaitTimeout,
+			}
+			return categoriesRepository, profileLock
+		},
+		BatchSize: *batchSize,
+		DryRun:    *dryRun,
+	}
+	if *rate > 0 {
+		syncer.Interval = time.Duration(float64(time.Second) / *rate)
+	}
+	if *stateFile != "" {
+		syncer.Checkpoint = template_sync.FileCheckpoint{Path: *stateFile}
+	}
+
+	filter := store.ProfileFilter{UserId: *userId}
+	if *profileIds != "" {
+		filter.ProfileIds = strings.Split(*profileIds, ",")
+	}
+
+	report, err := syncer.Run(ctx, filter)
+	if errors.Is(err, template_sync.ErrCheckpointMismatch) {
+		fmt.Fprintf(stderr, "%v, run it again with the same flags, use another -state-file or delete %s\n", err, *stateFile)
+		return 1
+	}
+
+	if *format == jsonFormat {
+		if err := writeJson(stdout, report); err != nil {
+			fmt.Fprintf(stderr, "failed to write output: %v\n", err)
+			return 1
+		}
+	} else {
+		writeSyncReport(stdout, report)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "sync stopped: %v\n", err)
+		if *stateFile != "" {
+			fmt.Fprintf(stderr, "run it again with -state-file %s to resume\n", *stateFile)
+		}
+		return 1
+	}
+	if report.ProfilesFailed > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func writeSyncReport(w io.Writer, report models.SyncReport) {
+	action := "synced"
+	if report.DryRun {
+		action = "planned sync of"
+	}
+	fmt.Fprintf(w, "%s %d profiles to %s\n", action, report.ProfilesScanned, report.TemplateVersion)
+	fmt.Fprintf(w, "updated: %d, up to date: %d, skipped for another template: %d, skipped without a template: %d, failed: %d\n",
+		report.ProfilesUpdated, report.ProfilesUpToDate, report.ProfilesSkipped, report.ProfilesWithoutTemplate, report.ProfilesFailed)
+	fmt.Fprintf(w, "added %d categories and %d subcategories, kept %d customised entries\n",
+		report.CategoriesAdded, report.SubcategoriesAdded, report.CustomisedEntriesKept)
+
+	for _, failure := range report.Failures {
+		fmt.Fprintf(w, "! %s: %s\n", failure.ProfileId, failure.Error)
+	}
+
+	if !report.Completed {
+		fmt.Fprintln(w, "sync did not complete")
+	}
+}
@@ -0,0 +1,46 @@
+//go:build !integrationTest
+
+package main
+
+import (
+	"bytes"
+	"categoryInitialiser/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSync(t *testing.T) {
+	t.Run("given negative rate, when sync run, then usage error returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runSync([]string{"-rate", "-1"}, &stdout, &stderr)
+
+		assert.Equal(t, 2, exitCode)
+	})
+}
+
+func TestWriteSyncReport(t *testing.T) {
+	t.Run("given interrupted sync with failures, when writeSyncReport called, then summary and failures written", func(t *testing.T) {
+		var output bytes.Buffer
+
+		writeSyncReport(&output, models.SyncReport{
+			TemplateVersion:       "au-default@2",
+			ProfilesScanned:       4,
+			ProfilesUpdated:       2,
+			ProfilesUpToDate:      1,
+			ProfilesFailed:        1,
+			CategoriesAdded:       1,
+			SubcategoriesAdded:    3,
+			CustomisedEntriesKept: 2,
+			Failures:              []models.SyncFailure{{ProfileId: "profile", Error: "profile is locked by another initialisation"}},
+		})
+
+		assert.Equal(t, `synced 4 profiles to au-default@2
+updated: 2, up to date: 1, skipped for another template: 0, skipped without a template: 0, failed: 1
+added 1 categories and 3 subcategories, kept 2 customised entries
+! profile: profile is locked by another initialisation
+sync did not complete
+`, output.String())
+	})
+}
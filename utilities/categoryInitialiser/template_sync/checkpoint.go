@@ -0,0 +1,71 @@
+package template_sync
+
+import (
+	"categoryInitialiser/models"
+	"categoryInitialiser/store"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// SyncState is how far a sync has got. Profiles are synced in id order so the sync can resume after LastProfileId.
+// Filter and Baseline are kept so that a sync is only resumed with the profiles and baseline it started with.
+type SyncState struct {
+	LastProfileId string
+	Filter        store.ProfileFilter
+	Baseline      string
+	Report        models.SyncReport
+}
+
+type Checkpoint interface {
+	// Load returns the saved state, found is false if nothing has been saved yet
+	Load() (state SyncState, found bool, err error)
+	Save(state SyncState) error
+}
+
+// FileCheckpoint keeps the sync state in a JSON file
+type FileCheckpoint struct {
+	Path string
+}
+
+func (c FileCheckpoint) Load() (SyncState, bool, error) {
+	stateBytes, err := os.ReadFile(c.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return SyncState{}, false, nil
+	}
+	if err != nil {
+		return SyncState{}, false, err
+	}
+
+	var state SyncState
+	if err = json.Unmarshal(stateBytes, &state); err != nil {
+		return SyncState{}, false, err
+	}
+
+	return state, true, nil
+}
+
+// Save writes the state to a temporary file and renames it over Path so an interrupted write can't corrupt the state
+func (c FileCheckpoint) Save(state SyncState) error {
+	stateBytes, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temporaryFile, err := os.CreateTemp(filepath.Dir(c.Path), filepath.Base(c.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporaryFile.Name())
+
+	if _, err = temporaryFile.Write(stateBytes); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err = temporaryFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryFile.Name(), c.Path)
+}
@@ -0,0 +1,37 @@
+//go:build !integrationTest
+
+package template_sync
+
+import (
+	"categoryInitialiser/models"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCheckpoint(t *testing.T) {
+	t.Run("given no state file, when Load called, then not found", func(t *testing.T) {
+		checkpoint := FileCheckpoint{Path: filepath.Join(t.TempDir(), "sync.json")}
+
+		_, found, err := checkpoint.Load()
+
+		assert.Nil(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("given saved state, when Load called, then state returned", func(t *testing.T) {
+		checkpoint := FileCheckpoint{Path: filepath.Join(t.TempDir(), "sync.json")}
+		state := SyncState{
+			LastProfileId: "profile",
+			Report:        models.SyncReport{TemplateVersion: "au-default@2", ProfilesScanned: 1, Failures: []models.SyncFailure{}},
+		}
+
+		assert.Nil(t, checkpoint.Save(state))
+		loadedState, found, err := checkpoint.Load()
+
+		assert.Nil(t, err)
+		assert.True(t, found)
+		assert.Equal(t, state, loadedState)
+	})
+}
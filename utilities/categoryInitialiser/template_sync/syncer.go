@@ -0,0 +1,231 @@
+package template_sync
+
+import (
+	"categoryInitialiser/category_provider"
+	"categoryInitialiser/models"
+	"categoryInitialiser/store"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+const defaultBatchSize = 100
+
+var ErrCheckpointMismatch = errors.New("checkpoint belongs to a different sync")
+
+// Syncer rolls the entries added to a template out to existing profiles. It only ever adds categories and
+// subcategories, anything a user removed or renamed is left as it is, see diffProfile.
+type Syncer struct {
+	Profiles  store.ProfilesRepository
+	Templates *category_provider.TemplatePackRegistry
+	Target    category_provider.TemplatePack
+	// Baseline stands in for the recorded template of profiles that don't have one. Without a baseline those profiles
+	// are skipped, since there is no telling which of Target's entries their users removed or renamed.
+	Baseline *category_provider.TemplatePack
+	// NewRepositories returns the repository and lock used to sync a single profile. For dry runs the repository must
+	// be in dry run mode.
+	NewRepositories func(profile models.Profile) (store.CategoriesRepository, store.ProfileLock)
+	// Checkpoint is optional, with one an interrupted sync carries on where it stopped the next time it is run
+	Checkpoint Checkpoint
+	// Interval is the minimum time between syncing two profiles, zero means profiles are synced as fast as possible
+	Interval  time.Duration
+	BatchSize int
+	DryRun    bool
+}
+
+// Run syncs every profile matching filter. A profile that fails to sync is recorded in the report and the sync moves
+// on, but once ctx is done the sync stops and returns ctx's error along with the report so far.
+func (s *Syncer) Run(ctx context.Context, filter store.ProfileFilter) (models.SyncReport, error) {
+	state, err := s.loadState(filter)
+	if err != nil {
+		return models.SyncReport{}, err
+	}
+
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var limiter <-chan time.Time
+	if s.Interval > 0 {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	for {
+		profiles, err := s.Profiles.ListProfiles(ctx, filter, state.LastProfileId, batchSize)
+		if err != nil {
+			return state.Report, err
+		}
+
+		for _, profile := range profiles {
+			if limiter != nil {
+				select {
+				case <-ctx.Done():
+					return state.Report, ctx.Err()
+				case <-limiter:
+				}
+			}
+
+			report := state.Report
+			err := s.syncProfile(ctx, profile, &report)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				// the profile isn't recorded as done so a resumed sync tries it again
+				return state.Report, ctxErr
+			}
+			if err != nil {
+				log.Printf("failed to sync profile %s: %v", profile.ProfileId, err)
+				report.ProfilesFailed++
+				report.Failures = append(report.Failures, models.SyncFailure{ProfileId: profile.ProfileId, Error: err.Error()})
+			}
+
+			state.Report = report
+			state.LastProfileId = profile.ProfileId
+			if err := s.saveState(state); err != nil {
+				return state.Report, err
+			}
+		}
+
+		if len(profiles) < batchSize {
+			break
+		}
+	}
+
+	state.Report.Completed = true
+	return state.Report, s.saveState(state)
+}
+
+// loadState resumes from the checkpoint unless there isn't one or the sync it belongs to completed. A checkpoint of an
+// unfinished sync with another target, dry run flag, filter or baseline returns ErrCheckpointMismatch.
+func (s *Syncer) loadState(filter store.ProfileFilter) (SyncState, error) {
+	state := SyncState{
+		Filter: filter,
+		Report: models.SyncReport{
+			TemplateVersion: s.Target.TemplateVersion().String(),
+			DryRun:          s.DryRun,
+			Failures:        make([]models.SyncFailure, 0),
+		},
+	}
+	if s.Baseline != nil {
+		state.Baseline = s.Baseline.TemplateVersion().String()
+	}
+
+	if s.Checkpoint == nil {
+		return state, nil
+	}
+
+	savedState, found, err := s.Checkpoint.Load()
+	if err != nil {
+		return SyncState{}, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	if !found || savedState.Report.Completed {
+		return state, nil
+	}
+
+	if savedState.Report.TemplateVersion != state.Report.TemplateVersion || savedState.Report.DryRun != s.DryRun {
+		return SyncState{}, fmt.Errorf("%w: it is for %s with dry run %t", ErrCheckpointMismatch, savedState.Report.TemplateVersion, savedState.Report.DryRun)
+	}
+	if savedState.Filter.UserId != filter.UserId || !slices.Equal(savedState.Filter.ProfileIds, filter.ProfileIds) {
+		return SyncState{}, fmt.Errorf("%w: it is for user %q and profiles %q", ErrCheckpointMismatch, savedState.Filter.UserId, savedState.Filter.ProfileIds)
+	}
+	if savedState.Baseline != state.Baseline {
+		return SyncState{}, fmt.Errorf("%w: it is for baseline %q", ErrCheckpointMismatch, savedState.Baseline)
+	}
+
+	log.Printf("resuming sync to %s after profile %s", state.Report.TemplateVersion, savedState.LastProfileId)
+	return savedState, nil
+}
+
+func (s *Syncer) saveState(state SyncState) error {
+	if s.Checkpoint == nil {
+		return nil
+	}
+
+	if err := s.Checkpoint.Save(state); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// syncProfile adds the entries the profile is missing and records the target as the profile's template, so the next
+// sync only considers entries added after it
+func (s *Syncer) syncProfile(ctx context.Context, profile models.Profile, report *models.SyncReport) error {
+	report.ProfilesScanned++
+
+	recordedTemplate := profile.TemplateVersion
+	if recordedTemplate.Name != "" && recordedTemplate.Name != s.Target.Name {
+		report.ProfilesSkipped++
+		return nil
+	}
+	if recordedTemplate.Name != "" && recordedTemplate.Version >= s.Target.Version {
+		report.ProfilesUpToDate++
+		return nil
+	}
+
+	if recordedTemplate.Name == "" && s.Baseline == nil {
+		report.ProfilesWithoutTemplate++
+		return nil
+	}
+
+	var baseline []models.CategoryDto
+	if recordedTemplate.Name != "" {
+		baselinePack, err := s.Templates.Get(recordedTemplate.String())
+		if err != nil {
+			return err
+		}
+		baseline = baselinePack.Categories
+	} else {
+		baseline = s.Baseline.Categories
+	}
+
+	categoriesRepository, profileLock := s.NewRepositories(profile)
+
+	if err := profileLock.Acquire(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if err := profileLock.Release(ctx); err != nil {
+			log.Printf("failed to release profile lock, it will be released when its lease expires: %v", err)
+		}
+	}()
+
+	existingCategories, err := categoriesRepository.GetCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	categories, customisedEntriesKept := diffProfile(s.Target.Categories, baseline, existingCategories)
+
+	savedCategories, err := categoriesRepository.SaveCategories(ctx, s.Target.TemplateVersion(), categories)
+	if err != nil {
+		return err
+	}
+
+	var categoriesAdded, subcategoriesAdded int
+	for _, savedCategory := range savedCategories {
+		if savedCategory.Created {
+			categoriesAdded++
+		}
+		for _, savedSubcategory := range savedCategory.Subcategories {
+			if savedSubcategory.Created {
+				subcategoriesAdded++
+			}
+		}
+	}
+
+	if categoriesAdded+subcategoriesAdded > 0 {
+		report.ProfilesUpdated++
+	} else {
+		report.ProfilesUpToDate++
+	}
+	report.CategoriesAdded += categoriesAdded
+	report.SubcategoriesAdded += subcategoriesAdded
+	report.CustomisedEntriesKept += customisedEntriesKept
+
+	return nil
+}
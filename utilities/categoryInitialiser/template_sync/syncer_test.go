@@ -0,0 +1,259 @@
+//go:build !integrationTest
+
+package template_sync
+
+import (
+	"categoryInitialiser/category_provider"
+	"categoryInitialiser/models"
+	"categoryInitialiser/store"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProfilesRepository struct {
+	profiles []models.Profile
+}
+
+func (r *fakeProfilesRepository) ListProfiles(_ context.Context, _ store.ProfileFilter, afterProfileId string, limit int) ([]models.Profile, error) {
+	sort.Slice(r.profiles, func(i, j int) bool { return r.profiles[i].ProfileId < r.profiles[j].ProfileId })
+
+	profiles := make([]models.Profile, 0)
+	for _, profile := range r.profiles {
+		if profile.ProfileId > afterProfileId && len(profiles) < limit {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	return profiles, nil
+}
+
+func (r *fakeProfilesRepository) ListUninitialisedProfiles(context.Context) ([]models.Profile, error) {
+	return nil, nil
+}
+
+// fakeCategoriesRepository saves categories the way merge mode does, creating only what doesn't exist yet
+type fakeCategoriesRepository struct {
+	existing []models.Category
+	saved    []models.Category
+	err      error
+}
+
+func (r *fakeCategoriesRepository) GetCategories(context.Context) ([]models.Category, error) {
+	return r.existing, r.err
+}
+
+func (r *fakeCategoriesRepository) SaveCategories(_ context.Context, _ models.TemplateVersion, categories []models.Category) ([]models.SavedCategory, error) {
+	r.saved = append(r.saved, categories...)
+
+	savedCategories := make([]models.SavedCategory, 0)
+	for _, category := range categories {
+		var existingSubcategories []string
+		savedCategory := models.SavedCategory{CategoryName: category.CategoryName, TransactionType: category.TransactionType, Created: true}
+		for _, existingCategory := range r.existing {
+			if existingCategory.CategoryName == category.CategoryName {
+				savedCategory.Created = false
+				existingSubcategories = existingCategory.Subcategories
+			}
+		}
+
+		for _, subcategory := range category.Subcategories {
+			savedCategory.Subcategories = append(savedCategory.Subcategories, models.SavedSubcategory{
+				SubcategoryName: subcategory,
+				Created:         !containsFold(existingSubcategories, subcategory),
+			})
+		}
+		savedCategories = append(savedCategories, savedCategory)
+	}
+
+	return savedCategories, nil
+}
+
+type fakeProfileLock struct{}
+
+func (fakeProfileLock) Acquire(context.Context) error { return nil }
+func (fakeProfileLock) Release(context.Context) error { return nil }
+
+type memoryCheckpoint struct {
+	state *SyncState
+}
+
+func (c *memoryCheckpoint) Load() (SyncState, bool, error) {
+	if c.state == nil {
+		return SyncState{}, false, nil
+	}
+	return *c.state, true, nil
+}
+
+func (c *memoryCheckpoint) Save(state SyncState) error {
+	c.state = &state
+	return nil
+}
+
+func newTestSyncer(t *testing.T, profiles []models.Profile, repositories map[string]*fakeCategoriesRepository) *Syncer {
+	templates, err := category_provider.LoadTemplatePacks(fstest.MapFS{
+		"test@1.json": {Data: []byte(`{"name": "test", "version": 1, "categories": [
+			{"transactionType": "expense", "name": "Food", "subcategories": ["Groceries"]}
+		]}`)},
+		"test@2.json": {Data: []byte(`{"name": "test", "version": 2, "categories": [
+			{"transactionType": "expense", "name": "Food", "subcategories": ["Groceries", "Coffee"]},
+			{"transactionType": "expense", "name": "Subscriptions", "subcategories": ["Digital Subscriptions"]}
+		]}`)},
+	})
+	assert.Nil(t, err)
+
+	target, err := templates.Get("test@2")
+	assert.Nil(t, err)
+
+	return &Syncer{
+		Profiles:  &fakeProfilesRepository{profiles: profiles},
+		Templates: templates,
+		Target:    target,
+		NewRepositories: func(profile models.Profile) (store.CategoriesRepository, store.ProfileLock) {
+			return repositories[profile.ProfileId], fakeProfileLock{}
+		},
+		BatchSize: 2,
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	t.Run("given profiles on older, current and other templates, when Run called, then only older profiles synced and failures isolated", func(t *testing.T) {
+		repositories := map[string]*fakeCategoriesRepository{
+			"1": {existing: []models.Category{{CategoryName: "Food", TransactionType: "expense", Subcategories: []string{"Groceries"}}}},
+			"4": {err: errors.New("connection reset")},
+			"5": {existing: []models.Category{{CategoryName: "Eating out", TransactionType: "expense", Subcategories: []string{}}}},
+		}
+		syncer := newTestSyncer(t, []models.Profile{
+			{ProfileId: "1", TemplateVersion: models.TemplateVersion{Name: "test", Version: 1}},
+			{ProfileId: "2", TemplateVersion: models.TemplateVersion{Name: "test", Version: 2}},
+			{ProfileId: "3", TemplateVersion: models.TemplateVersion{Name: "other", Version: 1}},
+			{ProfileId: "4", TemplateVersion: models.TemplateVersion{Name: "test", Version: 1}},
+			{ProfileId: "5", TemplateVersion: models.TemplateVersion{Name: "test", Version: 1}},
+		}, repositories)
+
+		report, err := syncer.Run(context.Background(), store.ProfileFilter{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.SyncReport{
+			TemplateVersion:       "test@2",
+			Completed:             true,
+			ProfilesScanned:       5,
+			ProfilesUpdated:       2,
+			ProfilesUpToDate:      1,
+			ProfilesSkipped:       1,
+			ProfilesFailed:        1,
+			CategoriesAdded:       2,
+			SubcategoriesAdded:    3,
+			CustomisedEntriesKept: 1,
+			Failures:              []models.SyncFailure{{ProfileId: "4", Error: "connection reset"}},
+		}, report)
+		assert.Equal(t, []models.Category{
+			{CategoryName: "Food", TransactionType: "expense", Subcategories: []string{"Groceries", "Coffee"}},
+			{CategoryName: "Subscriptions", TransactionType: "expense", SortOrder: 1, Subcategories: []string{"Digital Subscriptions"}},
+		}, repositories["1"].saved)
+	})
+
+	t.Run("given checkpoint of interrupted sync, when Run called, then sync resumed after last profile", func(t *testing.T) {
+		repositories := map[string]*fakeCategoriesRepository{"1": {}, "2": {}}
+		syncer := newTestSyncer(t, []models.Profile{
+			{ProfileId: "1", TemplateVersion: models.TemplateVersion{Name: "test", Version: 1}},
+			{ProfileId: "2", TemplateVersion: models.TemplateVersion{Name: "test", Version: 1}},
+		}, repositories)
+		syncer.Checkpoint = &memoryCheckpoint{state: &SyncState{
+			LastProfileId: "1",
+			Report:        models.SyncReport{TemplateVersion: "test@2", ProfilesScanned: 1, ProfilesUpdated: 1, Failures: []models.SyncFailure{}},
+		}}
+
+		report, err := syncer.Run(context.Background(), store.ProfileFilter{})
+
+		assert.Nil(t, err)
+		assert.Empty(t, repositories["1"].saved)
+		assert.Len(t, repositories["2"].saved, 1)
+		assert.Equal(t, 2, report.ProfilesScanned)
+		assert.Equal(t, 2, report.ProfilesUpdated)
+		assert.True(t, syncer.Checkpoint.(*memoryCheckpoint).state.Report.Completed)
+	})
+
+	t.Run("given profile without a recorded template whose user deleted a template category, when Run called without baseline, then profile skipped", func(t *testing.T) {
+		repositories := map[string]*fakeCategoriesRepository{
+			"1": {existing: []models.Category{{CategoryName: "Food", TransactionType: "expense", Subcategories: []string{"Groceries", "Coffee"}}}},
+		}
+		syncer := newTestSyncer(t, []models.Profile{{ProfileId: "1"}}, repositories)
+
+		report, err := syncer.Run(context.Background(), store.ProfileFilter{})
+
+		assert.Nil(t, err)
+		assert.Empty(t, repositories["1"].saved)
+		assert.Equal(t, 1, report.ProfilesScanned)
+		assert.Equal(t, 1, report.ProfilesWithoutTemplate)
+		assert.Equal(t, 0, report.ProfilesUpdated)
+	})
+
+	t.Run("given profile without a recorded template whose user deleted a template category, when Run called with baseline, then deleted category not added back", func(t *testing.T) {
+		repositories := map[string]*fakeCategoriesRepository{
+			"1": {existing: []models.Category{{CategoryName: "Eating out", TransactionType: "expense", Subcategories: []string{"Lunch"}}}},
+		}
+		syncer := newTestSyncer(t, []models.Profile{{ProfileId: "1"}}, repositories)
+		baseline, err := syncer.Templates.Get("test@1")
+		assert.Nil(t, err)
+		syncer.Baseline = &baseline
+
+		report, err := syncer.Run(context.Background(), store.ProfileFilter{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, report.ProfilesWithoutTemplate)
+		assert.Equal(t, []models.Category{
+			{CategoryName: "Subscriptions", TransactionType: "expense", SortOrder: 1, Subcategories: []string{"Digital Subscriptions"}},
+		}, repositories["1"].saved)
+	})
+
+	t.Run("given checkpoint of sync to another template, when Run called, then ErrCheckpointMismatch returned", func(t *testing.T) {
+		syncer := newTestSyncer(t, []models.Profile{}, nil)
+		syncer.Checkpoint = &memoryCheckpoint{state: &SyncState{Report: models.SyncReport{TemplateVersion: "test@1"}}}
+
+		_, err := syncer.Run(context.Background(), store.ProfileFilter{})
+
+		assert.ErrorIs(t, err, ErrCheckpointMismatch)
+	})
+
+	t.Run("given checkpoint of sync with another filter or baseline, when Run called, then ErrCheckpointMismatch returned", func(t *testing.T) {
+		checkpoints := map[string]SyncState{
+			"user":     {Filter: store.ProfileFilter{UserId: "another-user"}},
+			"profiles": {Filter: store.ProfileFilter{ProfileIds: []string{"1", "3"}}},
+			"baseline": {Filter: store.ProfileFilter{ProfileIds: []string{"1", "2"}}, Baseline: "test@1"},
+		}
+		for name, state := range checkpoints {
+			t.Run(name, func(t *testing.T) {
+				repositories := map[string]*fakeCategoriesRepository{"1": {}, "2": {}}
+				syncer := newTestSyncer(t, []models.Profile{{ProfileId: "1"}, {ProfileId: "2"}}, repositories)
+				state.LastProfileId = "1"
+				state.Report = models.SyncReport{TemplateVersion: "test@2", Failures: []models.SyncFailure{}}
+				syncer.Checkpoint = &memoryCheckpoint{state: &state}
+
+				_, err := syncer.Run(context.Background(), store.ProfileFilter{ProfileIds: []string{"1", "2"}})
+
+				assert.ErrorIs(t, err, ErrCheckpointMismatch)
+				assert.Empty(t, repositories["2"].saved)
+			})
+		}
+	})
+
+	t.Run("given cancelled context, when Run called, then sync stopped without recording the profile as done", func(t *testing.T) {
+		repositories := map[string]*fakeCategoriesRepository{"1": {}}
+		syncer := newTestSyncer(t, []models.Profile{{ProfileId: "1"}}, repositories)
+		syncer.Checkpoint = &memoryCheckpoint{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report, err := syncer.Run(ctx, store.ProfileFilter{})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, report.Completed)
+		assert.Equal(t, 0, report.ProfilesScanned)
+		assert.Nil(t, syncer.Checkpoint.(*memoryCheckpoint).state)
+	})
+}
@@ -0,0 +1,94 @@
+package template_sync
+
+import (
+	"categoryInitialiser/models"
+	"strings"
+)
+
+type categoryKey struct {
+	TransactionType string
+	Name            string
+}
+
+func newCategoryKey(transactionType string, name string) categoryKey {
+	return categoryKey{TransactionType: strings.ToLower(transactionType), Name: strings.ToLower(name)}
+}
+
+// diffProfile returns the categories to save so that a profile gains the entries of target it is missing. Existing
+// categories that gain subcategories are returned with their existing subcategories first, so the new ones are sorted
+// after them, and new categories are sorted after the profile's existing ones.
+//
+// An entry that is missing from the profile but is also in baseline, the template the profile was last initialised or
+// synced from, was removed or renamed by the user. It is left out and counted in customisedEntriesKept. Names are
+// matched ignoring case.
+func diffProfile(target []models.CategoryDto, baseline []models.CategoryDto, existing []models.Category) (categories []models.Category, customisedEntriesKept int) {
+	existingCategories := make(map[categoryKey]models.Category)
+	nextSortOrders := make(map[string]int)
+	for _, category := range existing {
+		existingCategories[newCategoryKey(category.TransactionType, category.CategoryName)] = category
+		if category.SortOrder >= nextSortOrders[category.TransactionType] {
+			nextSortOrders[category.TransactionType] = category.SortOrder + 1
+		}
+	}
+
+	baselineCategories := make(map[categoryKey][]string)
+	for _, category := range baseline {
+		baselineCategories[newCategoryKey(category.CategoryType, category.CategoryName)] = category.Subcategories
+	}
+
+	categories = make([]models.Category, 0)
+	for _, targetCategory := range target {
+		key := newCategoryKey(targetCategory.CategoryType, targetCategory.CategoryName)
+		baselineSubcategories, inBaseline := baselineCategories[key]
+
+		existingCategory, exists := existingCategories[key]
+		if !exists {
+			if inBaseline {
+				customisedEntriesKept++
+				continue
+			}
+
+			categories = append(categories, models.Category{
+				CategoryName:    targetCategory.CategoryName,
+				TransactionType: key.TransactionType,
+				Subcategories:   append([]string{}, targetCategory.Subcategories...),
+				SortOrder:       nextSortOrders[key.TransactionType],
+			})
+			nextSortOrders[key.TransactionType]++
+			continue
+		}
+
+		newSubcategories := make([]string, 0)
+		for _, subcategory := range targetCategory.Subcategories {
+			if containsFold(existingCategory.Subcategories, subcategory) {
+				continue
+			}
+			if containsFold(baselineSubcategories, subcategory) {
+				customisedEntriesKept++
+				continue
+			}
+			newSubcategories = append(newSubcategories, subcategory)
+		}
+
+		if len(newSubcategories) > 0 {
+			categories = append(categories, models.Category{
+				CategoryName:    existingCategory.CategoryName,
+				TransactionType: existingCategory.TransactionType,
+				Subcategories:   append(append([]string{}, existingCategory.Subcategories...), newSubcategories...),
+				SortOrder:       existingCategory.SortOrder,
+			})
+		}
+	}
+
+	return categories, customisedEntriesKept
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
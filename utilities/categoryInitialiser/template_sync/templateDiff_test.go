@@ -0,0 +1,71 @@
+//go:build !integrationTest
+
+package template_sync
+
+import (
+	"categoryInitialiser/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffProfile(t *testing.T) {
+	baseline := []models.CategoryDto{
+		{CategoryName: "Food", CategoryType: "expense", Subcategories: []string{"Groceries", "Restaurants"}},
+		{CategoryName: "Transport", CategoryType: "expense", Subcategories: []string{"Fuel"}},
+	}
+	target := []models.CategoryDto{
+		{CategoryName: "Food", CategoryType: "expense", Subcategories: []string{"Groceries", "Restaurants", "Coffee"}},
+		{CategoryName: "Transport", CategoryType: "expense", Subcategories: []string{"Fuel", "Tolls"}},
+		{CategoryName: "Subscriptions", CategoryType: "expense", Subcategories: []string{"Digital Subscriptions"}},
+	}
+
+	t.Run("given profile initialised from baseline, when diffProfile called, then only entries added since baseline returned", func(t *testing.T) {
+		categories, customisedEntriesKept := diffProfile(target, baseline, []models.Category{
+			{CategoryName: "Food", TransactionType: "expense", SortOrder: 0, Subcategories: []string{"Groceries", "Restaurants"}},
+			{CategoryName: "Transport", TransactionType: "expense", SortOrder: 1, Subcategories: []string{"Fuel"}},
+		})
+
+		assert.Equal(t, []models.Category{
+			{CategoryName: "Food", TransactionType: "expense", SortOrder: 0, Subcategories: []string{"Groceries", "Restaurants", "Coffee"}},
+			{CategoryName: "Transport", TransactionType: "expense", SortOrder: 1, Subcategories: []string{"Fuel", "Tolls"}},
+			{CategoryName: "Subscriptions", TransactionType: "expense", SortOrder: 2, Subcategories: []string{"Digital Subscriptions"}},
+		}, categories)
+		assert.Equal(t, 0, customisedEntriesKept)
+	})
+
+	t.Run("given profile that renamed a category and removed a subcategory, when diffProfile called, then customised entries left alone", func(t *testing.T) {
+		categories, customisedEntriesKept := diffProfile(target, baseline, []models.Category{
+			{CategoryName: "Eating", TransactionType: "expense", SortOrder: 0, Subcategories: []string{"Groceries", "Restaurants"}},
+			{CategoryName: "Transport", TransactionType: "expense", SortOrder: 1, Subcategories: []string{}},
+		})
+
+		assert.Equal(t, []models.Category{
+			{CategoryName: "Transport", TransactionType: "expense", SortOrder: 1, Subcategories: []string{"Tolls"}},
+			{CategoryName: "Subscriptions", TransactionType: "expense", SortOrder: 2, Subcategories: []string{"Digital Subscriptions"}},
+		}, categories)
+		assert.Equal(t, 2, customisedEntriesKept)
+	})
+
+	t.Run("given existing names in a different case, when diffProfile called, then existing names matched and kept", func(t *testing.T) {
+		categories, _ := diffProfile(target, baseline, []models.Category{
+			{CategoryName: "food", TransactionType: "expense", SortOrder: 0, Subcategories: []string{"groceries", "restaurants", "coffee"}},
+			{CategoryName: "transport", TransactionType: "expense", SortOrder: 1, Subcategories: []string{"fuel", "tolls"}},
+			{CategoryName: "subscriptions", TransactionType: "expense", SortOrder: 2, Subcategories: []string{"digital subscriptions"}},
+		})
+
+		assert.Empty(t, categories)
+	})
+
+	t.Run("given no baseline, when diffProfile called, then every missing entry returned", func(t *testing.T) {
+		categories, customisedEntriesKept := diffProfile(target, nil, []models.Category{
+			{CategoryName: "Food", TransactionType: "expense", SortOrder: 0, Subcategories: []string{"Groceries", "Restaurants", "Coffee"}},
+		})
+
+		assert.Equal(t, []models.Category{
+			{CategoryName: "Transport", TransactionType: "expense", SortOrder: 1, Subcategories: []string{"Fuel", "Tolls"}},
+			{CategoryName: "Subscriptions", TransactionType: "expense", SortOrder: 2, Subcategories: []string{"Digital Subscriptions"}},
+		}, categories)
+		assert.Equal(t, 0, customisedEntriesKept)
+	})
+}
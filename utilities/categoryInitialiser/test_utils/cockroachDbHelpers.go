@@ -25,3 +25,8 @@ func (c *CockroachDbHelpers) CreateProfile(profileName string) (createdProfileId
 	err = c.Connection.QueryRow(context.Background(), `INSERT INTO profile (display_name) VALUES ($1) RETURNING id`, profileName).Scan(&createdProfileId)
 	return
 }
+
+func (c *CockroachDbHelpers) CreateUserProfile(userId string, profileId string) error {
+	_, err := c.Connection.Exec(context.Background(), `INSERT INTO userprofile (user_id, profile_id) VALUES ($1, $2)`, userId, profileId)
+	return err
+}
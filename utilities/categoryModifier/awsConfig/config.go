@@ -8,24 +8,40 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 )
 
+const (
+	region                 = "ap-southeast-2"
+	localstackEndpoint     = "http://localhost:4566"
+	developmentEnvironment = "dev"
+)
+
 func GetConfig(environment string) aws.Config {
-	if environment == "dev" {
-		cfg, _ := config.LoadDefaultConfig(context.TODO(), config.WithRegion("ap-southeast-2"),
-			config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(func(_, _ string, _ ...interface{}) (aws.Endpoint, error) {
-				return aws.Endpoint{
-					URL:           "http://localhost:4566",
-					SigningRegion: "ap-southeast-2",
-				}, nil
-			})),
-			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("dummy", "dummy", "dummy")))
-		return cfg
-
-	} else {
-		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("ap-southeast-2"))
-		if err != nil {
-			panic(err)
+	cfg, err := LoadConfig(environment, "")
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// LoadConfig loads the AWS configuration for environment. dev talks to localstack with dummy credentials, every other
+// environment uses the default credential chain. A non-empty endpoint overrides the endpoint of every service.
+func LoadConfig(environment string, endpoint string) (aws.Config, error) {
+	options := []func(*config.LoadOptions) error{config.WithRegion(region)}
+
+	if environment == developmentEnvironment {
+		if endpoint == "" {
+			endpoint = localstackEndpoint
 		}
-		return cfg
+		options = append(options, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("dummy", "dummy", "dummy")))
+	}
+
+	if endpoint != "" {
+		options = append(options, config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(func(_, _ string, _ ...interface{}) (aws.Endpoint, error) {
+			return aws.Endpoint{
+				URL:           endpoint,
+				SigningRegion: region,
+			}, nil
+		})))
 	}
 
+	return config.LoadDefaultConfig(context.TODO(), options...)
 }
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"categoryModifier/models"
+	"categoryModifier/remap"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// environments are the environments with a MoneyMate transaction table
+var environments = []string{"dev", "prod"}
+
+var backends = []string{dynamoDbBackend, cockroachDbBackend}
+
+var errUsage = errors.New("invalid arguments")
+
+const usage = `usage: categoryModifier <command> [flags]
+
+commands:
+  rename   move the transactions of a user or profile from one category to another, or as a file of rules says
+
+run "categoryModifier <command> -help" for the flags of a command
+`
+
+// runCommand runs the subcommand named by the first argument and returns the process exit code: 0 on success, 1 if
+// the command failed and 2 if it was called with invalid arguments
+func runCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "rename":
+		return runRename(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+func runRename(args []string, stdout io.Writer, stderr io.Writer) int {
+	params, err := parseRenameArgs(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	report, err := startCategoryModifier(params)
+	if err == nil || len(report.Changes) > 0 {
+		if writeErr := report.write(stdout, params.output, len(params.getScopeIds())); writeErr != nil {
+			fmt.Fprintln(stderr, writeErr)
+			return 1
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// parseRenameArgs parses and validates the flags of the rename command. Problems are written to stderr and returned as
+// errUsage, or flag.ErrHelp if help was asked for.
+func parseRenameArgs(args []string, stderr io.Writer) (Parameters, error) {
+	flags := flag.NewFlagSet("rename", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: categoryModifier rename -environment <env> (-user-id <id> | -user-ids-file <file>) (-old-category <name> -new-category <name> | -rules <file>) [flags]")
+		fmt.Fprintln(stderr, "       categoryModifier rename -backend cockroachdb -environment <env> (-profile-id <id> | -profile-ids-file <file>) (-old-category <name> -new-category <name> | -rules <file>) [flags]")
+		flags.PrintDefaults()
+	}
+
+	var params Parameters
+	subcategoryMapping := subcategoryMappingFlag{}
+	flags.StringVar(&params.environment, "environment", "", "environment to modify, one of "+strings.Join(environments, ", "))
+	flags.StringVar(&params.backend, "backend", dynamoDbBackend, "database to modify, one of "+strings.Join(backends, ", "))
+	userId := flags.String("user-id", "", "id of the user whose transactions are modified, e.g. auth0|123, for the dynamodb backend")
+	userIdsFile := flags.String("user-ids-file", "", "file with the ids of the users whose transactions are modified, one per line")
+	profileId := flags.String("profile-id", "", "id of the profile whose transactions are modified, for the cockroachdb backend")
+	profileIdsFile := flags.String("profile-ids-file", "", "file with the ids of the profiles whose transactions are modified, one per line")
+	oldCategory := flags.String("old-category", "", "category to move transactions from")
+	oldSubcategory := flags.String("old-subcategory", "", "only move transactions in this subcategory of -old-category")
+	newCategory := flags.String("new-category", "", "category to move transactions to")
+	newSubcategory := flags.String("new-subcategory", "", "subcategory of -new-category to move transactions to, instead of the one with the same name")
+	flags.Var(subcategoryMapping, "subcategory", "`old=new` subcategory to move transactions to, can be repeated. Subcategories without one keep their name")
+	rulesFile := flags.String("rules", "", "YAML or CSV file of rules to apply instead of -old-category and -new-category, see the README")
+	flags.BoolVar(&params.createSubcategories, "create-subcategories", false, "create subcategories -new-category doesn't have yet instead of refusing to move transactions to them, for the cockroachdb backend")
+	flags.BoolVar(&params.dryRun, "dry-run", false, "list the transactions that would be moved without moving them")
+	flags.StringVar(&params.output, "output", tableOutput, "format of the report of moved transactions, one of "+strings.Join(outputs, ", "))
+	flags.StringVar(&params.tableName, "table-name", "", "DynamoDB table to modify instead of MoneyMate_TransactionDB_<environment>")
+	flags.StringVar(&params.endpoint, "endpoint", "", "AWS endpoint to use instead of the environment's, e.g. http://localhost:4566")
+	flags.StringVar(&params.databaseUrl, "database-url", "", "CockroachDB connection string, defaults to the local database in dev")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return Parameters{}, err
+		}
+		return Parameters{}, errUsage
+	}
+
+	if flags.NArg() > 0 {
+		return Parameters{}, usageError(stderr, "unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	if !contains(environments, params.environment) {
+		return Parameters{}, usageError(stderr, "-environment must be one of %s", strings.Join(environments, ", "))
+	}
+
+	if !contains(outputs, params.output) {
+		return Parameters{}, usageError(stderr, "-output must be one of %s", strings.Join(outputs, ", "))
+	}
+
+	var err error
+	switch params.backend {
+	case dynamoDbBackend:
+		if *profileId != "" || *profileIdsFile != "" || params.databaseUrl != "" {
+			return Parameters{}, usageError(stderr, "-profile-id, -profile-ids-file and -database-url can only be used with -backend %s", cockroachDbBackend)
+		}
+		if params.createSubcategories {
+			return Parameters{}, usageError(stderr, "-create-subcategories can only be used with -backend %s", cockroachDbBackend)
+		}
+		params.userIds, err = readIdFlags(stderr, "user", *userId, *userIdsFile)
+	case cockroachDbBackend:
+		if *userId != "" || *userIdsFile != "" || params.tableName != "" || params.endpoint != "" {
+			return Parameters{}, usageError(stderr, "-user-id, -user-ids-file, -table-name and -endpoint can only be used with -backend %s", dynamoDbBackend)
+		}
+		if params.getDatabaseUrl() == "" {
+			return Parameters{}, usageError(stderr, "-database-url is required outside of dev")
+		}
+		params.profileIds, err = readIdFlags(stderr, "profile", *profileId, *profileIdsFile)
+	default:
+		return Parameters{}, usageError(stderr, "-backend must be one of %s", strings.Join(backends, ", "))
+	}
+	if err != nil {
+		return Parameters{}, err
+	}
+
+	if *rulesFile != "" {
+		if *oldCategory != "" || *oldSubcategory != "" || *newCategory != "" || *newSubcategory != "" || len(subcategoryMapping) > 0 {
+			return Parameters{}, usageError(stderr, "-rules can't be used with -old-category, -old-subcategory, -new-category, -new-subcategory or -subcategory")
+		}
+
+		params.rules, err = remap.LoadRules(*rulesFile)
+		if err != nil {
+			return Parameters{}, usageError(stderr, "invalid -rules: %v", err)
+		}
+		return params, nil
+	}
+
+	if *oldCategory == "" || *newCategory == "" {
+		return Parameters{}, usageError(stderr, "-old-category and -new-category, or -rules, are required")
+	}
+	if *oldSubcategory != "" && len(subcategoryMapping) > 0 {
+		return Parameters{}, usageError(stderr, "-subcategory can't be used with -old-subcategory, use -new-subcategory instead")
+	}
+
+	params.rules = newRules(*oldCategory, *oldSubcategory, *newCategory, *newSubcategory, subcategoryMapping)
+	if !movesTransactions(params.rules) {
+		return Parameters{}, usageError(stderr, "the old and new categories and subcategories are the same, there is nothing to modify")
+	}
+
+	return params, nil
+}
+
+// newRules returns the rule moving -old-subcategory if one was given. Otherwise every subcategory of the old category
+// is moved, the ones in subcategoryMapping to their mapped subcategory and the rest to newSubcategory, or the
+// subcategory with the same name if that is empty.
+func newRules(oldCategory string, oldSubcategory string, newCategory string, newSubcategory string, subcategoryMapping subcategoryMappingFlag) []models.RemapRule {
+	if oldSubcategory != "" {
+		return []models.RemapRule{{OldCategory: oldCategory, OldSubcategory: oldSubcategory, NewCategory: newCategory, NewSubcategory: newSubcategory}}
+	}
+
+	mappedSubcategories := make([]string, 0, len(subcategoryMapping))
+	for mappedSubcategory := range subcategoryMapping {
+		mappedSubcategories = append(mappedSubcategories, mappedSubcategory)
+	}
+	sort.Strings(mappedSubcategories)
+
+	rules := make([]models.RemapRule, 0, len(subcategoryMapping)+1)
+	for _, mappedSubcategory := range mappedSubcategories {
+		rules = append(rules, models.RemapRule{
+			OldCategory:    oldCategory,
+			OldSubcategory: mappedSubcategory,
+			NewCategory:    newCategory,
+			NewSubcategory: subcategoryMapping[mappedSubcategory],
+		})
+	}
+
+	return append(rules, models.RemapRule{OldCategory: oldCategory, NewCategory: newCategory, NewSubcategory: newSubcategory})
+}
+
+// movesTransactions reports whether any of the rules can move a transaction somewhere else
+func movesTransactions(rules []models.RemapRule) bool {
+	for _, rule := range rules {
+		if rule.OldCategory != rule.NewCategory || (rule.NewSubcategory != "" && rule.NewSubcategory != rule.OldSubcategory) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readIdFlags returns the id given by the -<kind>-id flag or read from the -<kind>-ids-file flag, exactly one of which
+// must be provided
+func readIdFlags(stderr io.Writer, kind string, id string, idsFile string) ([]string, error) {
+	switch {
+	case id != "" && idsFile != "":
+		return nil, usageError(stderr, "only one of -%[1]s-id and -%[1]s-ids-file can be provided", kind)
+	case id != "":
+		return []string{id}, nil
+	case idsFile != "":
+		ids, err := readIds(idsFile)
+		if err != nil {
+			return nil, usageError(stderr, "failed to read -%s-ids-file: %v", kind, err)
+		}
+		return ids, nil
+	default:
+		return nil, usageError(stderr, "one of -%[1]s-id and -%[1]s-ids-file is required", kind)
+	}
+}
+
+func usageError(stderr io.Writer, format string, args ...interface{}) error {
+	fmt.Fprintf(stderr, format+"\n", args...)
+	return errUsage
+}
+
+// readIds reads one id per line, skipping blank lines and lines starting with #
+func readIds(fileName string) ([]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var ids []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" || strings.HasPrefix(id, "#") || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s has no ids", fileName)
+	}
+
+	return ids, nil
+}
+
+// subcategoryMappingFlag collects repeated -subcategory old=new flags
+type subcategoryMappingFlag map[string]string
+
+func (m subcategoryMappingFlag) String() string {
+	mappings := make([]string, 0, len(m))
+	for oldSubcategory, newSubcategory := range m {
+		mappings = append(mappings, oldSubcategory+"="+newSubcategory)
+	}
+	sort.Strings(mappings)
+
+	return strings.Join(mappings, ",")
+}
+
+func (m subcategoryMappingFlag) Set(value string) error {
+	oldSubcategory, newSubcategory, found := strings.Cut(value, "=")
+	oldSubcategory = strings.TrimSpace(oldSubcategory)
+	newSubcategory = strings.TrimSpace(newSubcategory)
+	if !found || oldSubcategory == "" || newSubcategory == "" {
+		return fmt.Errorf("%q should be old=new", value)
+	}
+
+	if _, exists := m[oldSubcategory]; exists {
+		return fmt.Errorf("subcategory %q is mapped more than once", oldSubcategory)
+	}
+
+	m[oldSubcategory] = newSubcategory
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
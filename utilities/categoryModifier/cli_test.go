@@ -0,0 +1,302 @@
+//go:build !integrationTest
+
+package main
+
+import (
+	"bytes"
+	"categoryModifier/models"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRenameArgs(t *testing.T) {
+	t.Run("given valid flags, when parseRenameArgs called, then parameters returned", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		params, err := parseRenameArgs([]string{
+			"-environment", "prod",
+			"-user-id", "auth0|123",
+			"-old-category", "Entertainment/Eating Out",
+			"-new-category", "Entertainment",
+			"-subcategory", "Lunch=Eating Out",
+			"-subcategory", "Dinner = Eating Out",
+			"-table-name", "MoneyMate_TransactionDB_copy",
+			"-endpoint", "http://localhost:4566",
+		}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Equal(t, Parameters{
+			environment: "prod",
+			backend:     "dynamodb",
+			userIds:     []string{"auth0|123"},
+			rules: []models.RemapRule{
+				{OldCategory: "Entertainment/Eating Out", OldSubcategory: "Dinner", NewCategory: "Entertainment", NewSubcategory: "Eating Out"},
+				{OldCategory: "Entertainment/Eating Out", OldSubcategory: "Lunch", NewCategory: "Entertainment", NewSubcategory: "Eating Out"},
+				{OldCategory: "Entertainment/Eating Out", NewCategory: "Entertainment"},
+			},
+			output:    "table",
+			tableName: "MoneyMate_TransactionDB_copy",
+			endpoint:  "http://localhost:4566",
+		}, params)
+		assert.Equal(t, "MoneyMate_TransactionDB_copy", params.getTableName())
+	})
+
+	t.Run("given no table name, when parseRenameArgs called, then environment's table used", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		params, err := parseRenameArgs([]string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b"}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "MoneyMate_TransactionDB_dev", params.getTableName())
+		assert.Equal(t, []models.RemapRule{{OldCategory: "a", NewCategory: "b"}}, params.rules)
+	})
+
+	t.Run("given old and new subcategory, when parseRenameArgs called, then only that subcategory moved", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		params, err := parseRenameArgs([]string{
+			"-environment", "dev",
+			"-user-id", "auth0|123",
+			"-old-category", "Eating Out",
+			"-old-subcategory", "Lunch",
+			"-new-category", "Food",
+			"-new-subcategory", "Lunch",
+		}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []models.RemapRule{{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food", NewSubcategory: "Lunch"}}, params.rules)
+	})
+
+	t.Run("given new subcategory only, when parseRenameArgs called, then every subcategory moved onto it", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		params, err := parseRenameArgs([]string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "Eating Out", "-new-category", "Food", "-new-subcategory", "Eating Out"}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []models.RemapRule{{OldCategory: "Eating Out", NewCategory: "Food", NewSubcategory: "Eating Out"}}, params.rules)
+	})
+
+	t.Run("given same category with new subcategory, when parseRenameArgs called, then subcategories merged within the category", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		params, err := parseRenameArgs([]string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "Food", "-old-subcategory", "Brunch", "-new-category", "Food", "-new-subcategory", "Lunch"}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Len(t, params.rules, 1)
+	})
+
+	t.Run("given rules file, when parseRenameArgs called, then rules read from it", func(t *testing.T) {
+		var stderr bytes.Buffer
+		rulesFile := filepath.Join(t.TempDir(), "rules.csv")
+		os.WriteFile(rulesFile, []byte("oldCategory,oldSubcategory,newCategory\nEating Out,Lunch,Food\nTakeaway,,Food\n"), 0o600)
+
+		params, err := parseRenameArgs([]string{"-environment", "dev", "-user-id", "auth0|123", "-rules", rulesFile}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []models.RemapRule{
+			{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food"},
+			{OldCategory: "Takeaway", NewCategory: "Food"},
+		}, params.rules)
+	})
+
+	t.Run("given --dry-run and json output, when parseRenameArgs called, then dry run with json report", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		params, err := parseRenameArgs([]string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b", "--dry-run", "-output", "json"}, &stderr)
+
+		assert.Nil(t, err)
+		assert.True(t, params.dryRun)
+		assert.Equal(t, "json", params.output)
+	})
+
+	t.Run("given user ids file, when parseRenameArgs called, then user ids read skipping blank lines, comments and duplicates", func(t *testing.T) {
+		var stderr bytes.Buffer
+		userIdsFile := filepath.Join(t.TempDir(), "users.txt")
+		os.WriteFile(userIdsFile, []byte("auth0|1\n\n# migrated already\n  auth0|2  \nauth0|1\n"), 0o600)
+
+		params, err := parseRenameArgs([]string{"-environment", "dev", "-user-ids-file", userIdsFile, "-old-category", "a", "-new-category", "b"}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"auth0|1", "auth0|2"}, params.userIds)
+	})
+
+	t.Run("given cockroachdb backend, when parseRenameArgs called, then profile ids and database url returned", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		params, err := parseRenameArgs([]string{
+			"-backend", "cockroachdb",
+			"-environment", "prod",
+			"-profile-id", "0f9e8d7c-6b5a-4c3d-2e1f-0a9b8c7d6e5f",
+			"-database-url", "postgresql://root@cockroachdb:26257/moneymate_db",
+			"-old-category", "a",
+			"-new-category", "b",
+		}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"0f9e8d7c-6b5a-4c3d-2e1f-0a9b8c7d6e5f"}, params.getScopeIds())
+		assert.Equal(t, "postgresql://root@cockroachdb:26257/moneymate_db", params.getDatabaseUrl())
+	})
+
+	t.Run("given cockroachdb backend in dev without database url, when parseRenameArgs called, then local database used", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		params, err := parseRenameArgs([]string{"-backend", "cockroachdb", "-environment", "dev", "-profile-id", "profile", "-old-category", "a", "-new-category", "b"}, &stderr)
+
+		assert.Nil(t, err)
+		assert.Equal(t, localCockroachDbConnectionString, params.getDatabaseUrl())
+	})
+
+	invalidArgs := map[string]struct {
+		args  []string
+		error string
+	}{
+		"unknown environment": {
+			args:  []string{"-environment", "staging", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b"},
+			error: "-environment must be one of dev, prod",
+		},
+		"no user": {
+			args:  []string{"-environment", "dev", "-old-category", "a", "-new-category", "b"},
+			error: "one of -user-id and -user-ids-file is required",
+		},
+		"user id and user ids file": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-user-ids-file", "users.txt", "-old-category", "a", "-new-category", "b"},
+			error: "only one of -user-id and -user-ids-file can be provided",
+		},
+		"missing user ids file": {
+			args:  []string{"-environment", "dev", "-user-ids-file", "does-not-exist.txt", "-old-category", "a", "-new-category", "b"},
+			error: "failed to read -user-ids-file",
+		},
+		"unknown backend": {
+			args:  []string{"-backend", "postgres", "-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b"},
+			error: "-backend must be one of dynamodb, cockroachdb",
+		},
+		"profile id with dynamodb backend": {
+			args:  []string{"-environment", "dev", "-profile-id", "profile", "-old-category", "a", "-new-category", "b"},
+			error: "-profile-id, -profile-ids-file and -database-url can only be used with -backend cockroachdb",
+		},
+		"user id with cockroachdb backend": {
+			args:  []string{"-backend", "cockroachdb", "-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b"},
+			error: "-user-id, -user-ids-file, -table-name and -endpoint can only be used with -backend dynamodb",
+		},
+		"no profile with cockroachdb backend": {
+			args:  []string{"-backend", "cockroachdb", "-environment", "dev", "-old-category", "a", "-new-category", "b"},
+			error: "one of -profile-id and -profile-ids-file is required",
+		},
+		"cockroachdb backend in prod without database url": {
+			args:  []string{"-backend", "cockroachdb", "-environment", "prod", "-profile-id", "profile", "-old-category", "a", "-new-category", "b"},
+			error: "-database-url is required outside of dev",
+		},
+		"no new category": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a"},
+			error: "-old-category and -new-category, or -rules, are required",
+		},
+		"same category without subcategory mapping": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "a"},
+			error: "there is nothing to modify",
+		},
+		"same subcategory": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-old-subcategory", "x", "-new-category", "a", "-new-subcategory", "x"},
+			error: "there is nothing to modify",
+		},
+		"subcategory mapping with old subcategory": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-old-subcategory", "x", "-new-category", "b", "-subcategory", "x=y"},
+			error: "-subcategory can't be used with -old-subcategory",
+		},
+		"unknown output": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b", "-output", "csv"},
+			error: "-output must be one of table, json",
+		},
+		"rules file with old category": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-rules", "rules.yaml", "-old-category", "a"},
+			error: "-rules can't be used with -old-category",
+		},
+		"missing rules file": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-rules", "does-not-exist.yaml"},
+			error: "invalid -rules",
+		},
+		"create subcategories with dynamodb backend": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b", "-create-subcategories"},
+			error: "-create-subcategories can only be used with -backend cockroachdb",
+		},
+		"subcategory mapping without new subcategory": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b", "-subcategory", "Lunch"},
+			error: `"Lunch" should be old=new`,
+		},
+		"subcategory mapped twice": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b", "-subcategory", "Lunch=x", "-subcategory", "Lunch=y"},
+			error: `subcategory "Lunch" is mapped more than once`,
+		},
+		"positional argument": {
+			args:  []string{"-environment", "dev", "-user-id", "auth0|123", "-old-category", "a", "-new-category", "b", "extra"},
+			error: "unexpected arguments: extra",
+		},
+	}
+	for name, testCase := range invalidArgs {
+		t.Run("given "+name+", when parseRenameArgs called, then usage error returned", func(t *testing.T) {
+			var stderr bytes.Buffer
+
+			_, err := parseRenameArgs(testCase.args, &stderr)
+
+			assert.ErrorIs(t, err, errUsage)
+			assert.Contains(t, stderr.String(), testCase.error)
+		})
+	}
+
+	t.Run("given -help, when parseRenameArgs called, then flag.ErrHelp returned and flags described", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		_, err := parseRenameArgs([]string{"--help"}, &stderr)
+
+		assert.ErrorIs(t, err, flag.ErrHelp)
+		assert.Contains(t, stderr.String(), "-user-ids-file")
+	})
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Run("given no command, when runCommand called, then usage written and exit code 2 returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runCommand([]string{}, &stdout, &stderr)
+
+		assert.Equal(t, 2, exitCode)
+		assert.Contains(t, stderr.String(), "usage: categoryModifier <command>")
+	})
+
+	t.Run("given unknown command, when runCommand called, then exit code 2 returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runCommand([]string{"delete"}, &stdout, &stderr)
+
+		assert.Equal(t, 2, exitCode)
+		assert.Contains(t, stderr.String(), `unknown command "delete"`)
+	})
+
+	t.Run("given --help, when runCommand called, then usage written and exit code 0 returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runCommand([]string{"--help"}, &stdout, &stderr)
+
+		assert.Equal(t, 0, exitCode)
+		assert.Contains(t, stdout.String(), "rename")
+	})
+
+	t.Run("given rename with --help, when runCommand called, then exit code 0 returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runCommand([]string{"rename", "--help"}, &stdout, &stderr)
+
+		assert.Equal(t, 0, exitCode)
+	})
+
+	t.Run("given rename with invalid flags, when runCommand called, then exit code 2 returned", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+
+		exitCode := runCommand([]string{"rename", "-environment", "dev"}, &stdout, &stderr)
+
+		assert.Equal(t, 2, exitCode)
+	})
+}
@@ -1,80 +1,194 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"os"
 
 	"categoryModifier/awsConfig"
+	"categoryModifier/models"
+	"categoryModifier/remap"
 	"categoryModifier/repository"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	dynamoDbBackend    = "dynamodb"
+	cockroachDbBackend = "cockroachdb"
+
+	localCockroachDbConnectionString = "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
 )
 
 type Parameters struct {
-	environment     string
-	userId          string
-	oldCatgoryName  string
-	newCategoryName string
+	environment string
+	backend     string
+	// userIds are used by the DynamoDB backend and profileIds by the CockroachDB backend
+	userIds    []string
+	profileIds []string
+	rules      []models.RemapRule
+	// createSubcategories lets transactions move to subcategories the new category doesn't have yet, only the
+	// CockroachDB backend creates them
+	createSubcategories bool
+	// dryRun plans the moves without writing them, output is the format of the report, one of outputs
+	dryRun bool
+	output string
+	// tableName and endpoint override the environment's DynamoDB table and AWS endpoint when they aren't empty
+	tableName string
+	endpoint  string
+	// databaseUrl is the CockroachDB connection string, it defaults to the local database in dev
+	databaseUrl string
+}
+
+func (p Parameters) getTableName() string {
+	if p.tableName != "" {
+		return p.tableName
+	}
+
+	return fmt.Sprintf("MoneyMate_TransactionDB_%v", p.environment)
+}
+
+func (p Parameters) getDatabaseUrl() string {
+	if p.databaseUrl == "" && p.environment == "dev" {
+		return localCockroachDbConnectionString
+	}
+
+	return p.databaseUrl
+}
+
+// getScopeIds returns the ids of the users or profiles whose transactions are modified
+func (p Parameters) getScopeIds() []string {
+	if p.backend == cockroachDbBackend {
+		return p.profileIds
+	}
+
+	return p.userIds
 }
 
-func initialiseDependencies(parameters Parameters) repository.MoneyMateDbRepository {
-	environment := parameters.environment
+// initialiseDependencies returns a function creating the repository of a user or profile, and a function to release
+// what the repositories share once they are no longer needed
+func initialiseDependencies(parameters Parameters) (newRepository func(scopeId string) repository.MoneyMateDbRepository, closeDependencies func(), err error) {
+	if parameters.backend == cockroachDbBackend {
+		connection, err := pgx.Connect(context.Background(), parameters.getDatabaseUrl())
+		if err != nil {
+			return nil, nil, err
+		}
 
-	cfg := awsConfig.GetConfig(environment)
+		newRepository = func(profileId string) repository.MoneyMateDbRepository {
+			return &repository.CockroachDbMoneyMateDbRepository{
+				ProfileId:  profileId,
+				Connection: connection,
+			}
+		}
+		return newRepository, func() { connection.Close(context.Background()) }, nil
+	}
 
-	moneymateDb := &repository.DynamoDbMoneyMateDbRepository{
-		UserId:    parameters.userId,
-		Client:    dynamodb.NewFromConfig(cfg),
-		TableName: fmt.Sprintf("MoneyMate_TransactionDB_%v", environment),
+	cfg, err := awsConfig.LoadConfig(parameters.environment, parameters.endpoint)
+	if err != nil {
+		return nil, nil, err
 	}
+	client := dynamodb.NewFromConfig(cfg)
 
-	return moneymateDb
+	newRepository = func(userId string) repository.MoneyMateDbRepository {
+		return &repository.DynamoDbMoneyMateDbRepository{
+			UserId:    userId,
+			Client:    client,
+			TableName: parameters.getTableName(),
+		}
+	}
+	return newRepository, func() {}, nil
 }
 
-func startCategoryModifier(params Parameters) {
+// startCategoryModifier moves the transactions of every user or profile in params as the rules say, or only plans the
+// moves in a dry run, and returns a report of them. Transactions that fail to update don't stop the others, the error
+// returned says how many could not be modified.
+func startCategoryModifier(params Parameters) (Report, error) {
+	report := newReport(params)
+
+	newRepository, closeDependencies, err := initialiseDependencies(params)
+	if err != nil {
+		return report, err
+	}
+	defer closeDependencies()
+
+	failedTransactions := 0
+
+	for _, scopeId := range params.getScopeIds() {
+		moves, failed, err := modifyCategory(params, newRepository(scopeId))
+		if err != nil {
+			return report, fmt.Errorf("failed to modify category for %s: %w", scopeId, err)
+		}
+
+		report.add(scopeId, moves)
+		failedTransactions += failed
+	}
+
+	if failedTransactions > 0 {
+		return report, fmt.Errorf("failed to modify %d transactions", failedTransactions)
+	}
 
-	moneymateDb := initialiseDependencies(params)
+	return report, nil
+}
 
-	transactions, _ := moneymateDb.GetTransactionsWithCategory(params.oldCatgoryName)
+// modifyCategory applies every rule in one pass and returns the moves that were made, or would be made in a dry run,
+// and the number of transactions that could not be updated. Nothing is updated if the rules conflict or any
+// transaction would be moved to a category or subcategory the user or profile doesn't have.
+func modifyCategory(params Parameters, moneymateDb repository.MoneyMateDbRepository) ([]remap.Move, int, error) {
+	categories, err := moneymateDb.GetCategories()
+	if err != nil {
+		return nil, 0, err
+	}
 
-	var transactionIds []string
-	for _, transaction := range transactions {
-		fmt.Println(transaction)
+	var transactions []models.Transaction
+	queriedCategories := make(map[string]bool)
+	for _, rule := range params.rules {
+		if queriedCategories[rule.OldCategory] {
+			continue
+		}
+		queriedCategories[rule.OldCategory] = true
 
-		if transaction.Category == params.oldCatgoryName {
-			transactionIds = append(transactionIds, transaction.Subquery)
+		categoryTransactions, err := moneymateDb.GetTransactionsWithCategory(rule.OldCategory)
+		if err != nil {
+			return nil, 0, err
 		}
+		transactions = append(transactions, categoryTransactions...)
+	}
+
+	moves, err := remap.Plan(transactions, params.rules, categories, remap.Options{AllowMissingSubcategories: params.createSubcategories})
+	if err != nil {
+		return nil, 0, err
 	}
 
-	fmt.Println(transactionIds)
+	if params.dryRun || len(moves) == 0 {
+		return moves, 0, nil
+	}
+
+	updates := make([]models.TransactionCategoryUpdate, 0, len(moves))
+	for _, move := range moves {
+		updates = append(updates, move.Update())
+	}
 
-	var wg sync.WaitGroup
-	for _, transactionId := range transactionIds {
-		wg.Add(1)
+	err = moneymateDb.UpdateTransactionsWithNewCategory(updates)
 
-		go func(transactionId string) {
-			defer wg.Done()
-			fmt.Println("Modifying category for transactionId", transactionId)
-			err := moneymateDb.UpdateTransactionWithNewCategory(transactionId, params.newCategoryName)
-			if err != nil {
-				fmt.Printf("error occurred updating transactionId: %s, error: %v\n", transactionId, err)
+	var updateError *repository.UpdateTransactionsError
+	if errors.As(err, &updateError) {
+		var moved []remap.Move
+		for _, move := range moves {
+			if _, failed := updateError.Failures[move.Transaction.Subquery]; !failed {
+				moved = append(moved, move)
 			}
-		}(transactionId)
+		}
+		return moved, len(updateError.Failures), nil
+	}
+	if err != nil {
+		return nil, 0, err
 	}
 
-	wg.Wait()
+	return moves, 0, nil
 }
 
 func main() {
-	environment := "prod"
-	userId := "auth0|jgv115"
-	interestedCategory := "Entertainment/Eating Out"
-	newCategoryName := "Entertainment"
-
-	startCategoryModifier(Parameters{
-		environment:     environment,
-		userId:          userId,
-		oldCatgoryName:  interestedCategory,
-		newCategoryName: newCategoryName,
-	})
+	os.Exit(runCommand(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -1,3 +1,8 @@
+//go:build integrationTest
+
+// These tests need localstack, so they only run with the integrationTest tag and go test ./... is left to the unit
+// tests, the same split as categoryInitialiser.
+
 package main
 
 import (
@@ -6,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -182,13 +188,22 @@ func Test_Integration(t *testing.T) {
 		InsertItemIntoMoneyMateDb(transaction)
 	}
 
-	startCategoryModifier(Parameters{
-		environment:     integrationTestFixture.Environment,
-		userId:          integrationTestFixture.UserId,
-		oldCatgoryName:  oldCategory,
-		newCategoryName: newCategory,
+	InsertItemIntoMoneyMateDb(map[string]interface{}{
+		"UserIdQuery":     fmt.Sprintf("%s#Categories", integrationTestFixture.UserId),
+		"Subquery":        newCategory,
+		"TransactionType": 0,
+		"Subcategories":   []string{subcategory},
 	})
 
+	report, err := startCategoryModifier(Parameters{
+		environment: integrationTestFixture.Environment,
+		backend:     dynamoDbBackend,
+		userIds:     []string{integrationTestFixture.UserId},
+		rules:       []models.RemapRule{{OldCategory: oldCategory, NewCategory: newCategory}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, report.Transactions)
+
 	expectedTransactions := make([]models.Transaction, len(transactions))
 	copy(expectedTransactions, transactions)
 
@@ -198,7 +213,106 @@ func Test_Integration(t *testing.T) {
 		}
 	}
 
-	scannedTransactions := GetAllItemsFromMoneyMateDb[models.Transaction]()
+	var scannedTransactions []models.Transaction
+	for _, item := range GetAllItemsFromMoneyMateDb[models.Transaction]() {
+		if strings.HasSuffix(item.UserIdQuery, "#Transaction") {
+			scannedTransactions = append(scannedTransactions, item)
+		}
+	}
 
 	assert.ElementsMatch(t, expectedTransactions, scannedTransactions)
 }
+
+func Test_IntegrationMissingSubcategory(t *testing.T) {
+	defer DeleteMoneyMateDb()
+
+	CreateTableMoneyMateDb()
+
+	transaction := models.Transaction{
+		UserIdQuery:          fmt.Sprintf("%s#Transaction", integrationTestFixture.UserId),
+		Subquery:             uuid.NewString(),
+		TransactionTimestamp: time.Now().Format("2016-02-01T15:04:05Z"),
+		TransactionType:      "expense",
+		Amount:               "12.5",
+		Category:             "Eating Out",
+		SubCategory:          "Brunch",
+	}
+	InsertItemIntoMoneyMateDb(transaction)
+
+	InsertItemIntoMoneyMateDb(map[string]interface{}{
+		"UserIdQuery":     fmt.Sprintf("%s#Categories", integrationTestFixture.UserId),
+		"Subquery":        "Food",
+		"TransactionType": 0,
+		"Subcategories":   []string{"Lunch"},
+	})
+
+	_, err := startCategoryModifier(Parameters{
+		environment: integrationTestFixture.Environment,
+		backend:     dynamoDbBackend,
+		userIds:     []string{integrationTestFixture.UserId},
+		rules:       []models.RemapRule{{OldCategory: "Eating Out", NewCategory: "Food"}},
+	})
+	assert.ErrorContains(t, err, "expense > Food > Brunch (1 transactions)")
+
+	var scannedTransactions []models.Transaction
+	for _, item := range GetAllItemsFromMoneyMateDb[models.Transaction]() {
+		if strings.HasSuffix(item.UserIdQuery, "#Transaction") {
+			scannedTransactions = append(scannedTransactions, item)
+		}
+	}
+	assert.Equal(t, []models.Transaction{transaction}, scannedTransactions)
+}
+
+func Test_IntegrationDryRun(t *testing.T) {
+	defer DeleteMoneyMateDb()
+
+	CreateTableMoneyMateDb()
+
+	transaction := models.Transaction{
+		UserIdQuery:          fmt.Sprintf("%s#Transaction", integrationTestFixture.UserId),
+		Subquery:             uuid.NewString(),
+		TransactionTimestamp: "2023-04-01T12:00:00Z",
+		TransactionType:      "expense",
+		Amount:               "12.5",
+		Category:             "Eating Out",
+		SubCategory:          "Lunch",
+		PayerPayeeName:       "Cafe",
+	}
+	InsertItemIntoMoneyMateDb(transaction)
+
+	InsertItemIntoMoneyMateDb(map[string]interface{}{
+		"UserIdQuery":     fmt.Sprintf("%s#Categories", integrationTestFixture.UserId),
+		"Subquery":        "Food",
+		"TransactionType": 0,
+		"Subcategories":   []string{"Lunch"},
+	})
+
+	report, err := startCategoryModifier(Parameters{
+		environment: integrationTestFixture.Environment,
+		backend:     dynamoDbBackend,
+		userIds:     []string{integrationTestFixture.UserId},
+		rules:       []models.RemapRule{{OldCategory: "Eating Out", NewCategory: "Food"}},
+		dryRun:      true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Change{{
+		ScopeId:        integrationTestFixture.UserId,
+		TransactionId:  transaction.Subquery,
+		Date:           "2023-04-01T12:00:00Z",
+		Amount:         "12.5",
+		PayerPayee:     "Cafe",
+		OldCategory:    "Eating Out",
+		OldSubcategory: "Lunch",
+		NewCategory:    "Food",
+		NewSubcategory: "Lunch",
+		Rule:           "Eating Out > * -> Food > *",
+	}}, report.Changes)
+
+	var scannedTransactions []models.Transaction
+	for _, item := range GetAllItemsFromMoneyMateDb[models.Transaction]() {
+		if strings.HasSuffix(item.UserIdQuery, "#Transaction") {
+			scannedTransactions = append(scannedTransactions, item)
+		}
+	}
+	assert.Equal(t, []models.Transaction{transaction}, scannedTransactions)
+}
@@ -0,0 +1,18 @@
+package models
+
+type Category struct {
+	Name            string
+	TransactionType string
+	Subcategories   []string
+}
+
+// HasSubcategory reports whether the category has a subcategory called name
+func (c Category) HasSubcategory(name string) bool {
+	for _, subcategory := range c.Subcategories {
+		if subcategory == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// DateFormat is the format of the From and To days of a RemapFilter
+const DateFormat = "2006-01-02"
+
+// RemapRule moves transactions from OldCategory to NewCategory. An empty OldSubcategory matches every subcategory of
+// OldCategory that doesn't have a rule of its own, and an empty NewSubcategory keeps the transaction's subcategory.
+type RemapRule struct {
+	OldCategory    string
+	OldSubcategory string
+	NewCategory    string
+	NewSubcategory string
+	Filter         RemapFilter
+}
+
+// RemapFilter narrows the transactions a rule moves, empty fields match every transaction
+type RemapFilter struct {
+	TransactionType string
+	PayerPayee      string
+	// From and To are the first and last day, in UTC, of the transactions to move
+	From time.Time
+	To   time.Time
+}
+
+// String formats the rule as "Eating Out > Lunch -> Food > Lunch", with * standing in for an empty subcategory and
+// the filter, if there is one, in brackets after it
+func (r RemapRule) String() string {
+	rule := formatRuleSide(r.OldCategory, r.OldSubcategory) + " -> " + formatRuleSide(r.NewCategory, r.NewSubcategory)
+
+	if filter := r.Filter.String(); filter != "" {
+		rule += " [" + filter + "]"
+	}
+
+	return rule
+}
+
+func (f RemapFilter) String() string {
+	var conditions []string
+
+	if f.TransactionType != "" {
+		conditions = append(conditions, "type "+f.TransactionType)
+	}
+	if f.PayerPayee != "" {
+		conditions = append(conditions, "payer/payee "+f.PayerPayee)
+	}
+	if !f.From.IsZero() {
+		conditions = append(conditions, "from "+f.From.Format(DateFormat))
+	}
+	if !f.To.IsZero() {
+		conditions = append(conditions, "to "+f.To.Format(DateFormat))
+	}
+
+	return strings.Join(conditions, ", ")
+}
+
+func formatRuleSide(category string, subcategory string) string {
+	if subcategory == "" {
+		subcategory = "*"
+	}
+
+	return category + " > " + subcategory
+}
@@ -0,0 +1,8 @@
+package models
+
+// TransactionCategoryUpdate moves a transaction to Category. Subcategory is left as it is when it is empty.
+type TransactionCategoryUpdate struct {
+	TransactionId string
+	Category      string
+	Subcategory   string
+}
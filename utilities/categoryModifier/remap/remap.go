@@ -0,0 +1,336 @@
+package remap
+
+import (
+	"categoryModifier/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Move is a transaction a rule moves and the category and subcategory it moves it to
+type Move struct {
+	Transaction    models.Transaction
+	Rule           models.RemapRule
+	NewCategory    string
+	NewSubcategory string
+}
+
+func (m Move) Update() models.TransactionCategoryUpdate {
+	return models.TransactionCategoryUpdate{
+		TransactionId: m.Transaction.Subquery,
+		Category:      m.NewCategory,
+		Subcategory:   m.NewSubcategory,
+	}
+}
+
+type Options struct {
+	// AllowMissingSubcategories lets transactions move to a subcategory the new category doesn't have yet, for
+	// repositories that create missing subcategories
+	AllowMissingSubcategories bool
+}
+
+// MissingTarget is a category and subcategory that transactions would be moved to but that doesn't exist
+type MissingTarget struct {
+	TransactionType string
+	Category        string
+	Subcategory     string
+	Transactions    int
+}
+
+func (t MissingTarget) String() string {
+	return fmt.Sprintf("%s (%d transactions)", formatTarget(t.TransactionType, t.Category, t.Subcategory), t.Transactions)
+}
+
+type MissingTargetsError struct {
+	Targets []MissingTarget
+}
+
+func (e *MissingTargetsError) Error() string {
+	targets := make([]string, 0, len(e.Targets))
+	for _, target := range e.Targets {
+		targets = append(targets, target.String())
+	}
+
+	return "transactions would be moved to categories that don't exist: " + strings.Join(targets, ", ")
+}
+
+// RuleConflict is a set of rules that all match the same transactions, so which one moves them is ambiguous
+type RuleConflict struct {
+	Rules        []models.RemapRule
+	Transactions int
+}
+
+func (c RuleConflict) String() string {
+	rules := make([]string, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		rules = append(rules, rule.String())
+	}
+
+	return fmt.Sprintf("%s (%d transactions)", strings.Join(rules, " and "), c.Transactions)
+}
+
+type ConflictingRulesError struct {
+	Conflicts []RuleConflict
+}
+
+func (e *ConflictingRulesError) Error() string {
+	conflicts := make([]string, 0, len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		conflicts = append(conflicts, conflict.String())
+	}
+
+	return "rules match the same transactions: " + strings.Join(conflicts, ", ")
+}
+
+// CyclicRemapError is returned when rules move transactions out of a category and subcategory and, through other
+// rules, back into it, e.g. Food > Lunch -> Food > Dinner and Food > Dinner -> Food > Lunch
+type CyclicRemapError struct {
+	// Cycle lists the transaction type, category and subcategory of each step, starting and ending with the same one
+	Cycle []string
+}
+
+func (e *CyclicRemapError) Error() string {
+	return "rules move transactions in a cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// Plan works out where rules move each of transactions, all rules applying to where transactions are before any of
+// them is moved. A rule for the transaction's subcategory takes precedence over a rule for its whole category, and
+// transactions that no rule matches or that would stay where they are are left out. Nothing is planned and an error is
+// returned if
+//   - more than one rule of the same precedence matches a transaction, as a ConflictingRulesError
+//   - any transaction would end up in a category or subcategory that isn't in categories, as a MissingTargetsError
+//   - transactions would be moved in a cycle, as a CyclicRemapError
+func Plan(transactions []models.Transaction, rules []models.RemapRule, categories []models.Category, options Options) ([]Move, error) {
+	moves := make([]Move, 0)
+	missingTargets := make(map[MissingTarget]int)
+	conflicts := make(map[string]*RuleConflict)
+	var conflictKeys []string
+
+	for _, transaction := range transactions {
+		matchingRules := findRules(rules, transaction)
+		if len(matchingRules) == 0 {
+			continue
+		}
+
+		if len(matchingRules) > 1 {
+			key := fmt.Sprint(matchingRules)
+			if _, exists := conflicts[key]; !exists {
+				conflicts[key] = &RuleConflict{Rules: matchingRules}
+				conflictKeys = append(conflictKeys, key)
+			}
+			conflicts[key].Transactions++
+			continue
+		}
+
+		rule := matchingRules[0]
+		move := Move{
+			Transaction:    transaction,
+			Rule:           rule,
+			NewCategory:    rule.NewCategory,
+			NewSubcategory: rule.NewSubcategory,
+		}
+		if move.NewSubcategory == "" {
+			move.NewSubcategory = transaction.SubCategory
+		}
+
+		if move.NewCategory == transaction.Category && move.NewSubcategory == transaction.SubCategory {
+			continue
+		}
+
+		if !targetExists(categories, transaction.TransactionType, move.NewCategory, move.NewSubcategory, options) {
+			missingTargets[MissingTarget{
+				TransactionType: transaction.TransactionType,
+				Category:        move.NewCategory,
+				Subcategory:     move.NewSubcategory,
+			}]++
+			continue
+		}
+
+		moves = append(moves, move)
+	}
+
+	if len(conflicts) > 0 {
+		conflictingRulesError := &ConflictingRulesError{}
+		for _, key := range conflictKeys {
+			conflictingRulesError.Conflicts = append(conflictingRulesError.Conflicts, *conflicts[key])
+		}
+		return nil, conflictingRulesError
+	}
+
+	if len(missingTargets) > 0 {
+		return nil, newMissingTargetsError(missingTargets)
+	}
+
+	if cycle := findCycle(moves); cycle != nil {
+		return nil, &CyclicRemapError{Cycle: cycle}
+	}
+
+	return moves, nil
+}
+
+// CountByRule returns how many of moves each of rules makes, in the same order as rules
+func CountByRule(rules []models.RemapRule, moves []Move) []int {
+	counts := make([]int, len(rules))
+
+	for _, move := range moves {
+		for i, rule := range rules {
+			if rule == move.Rule {
+				counts[i]++
+				break
+			}
+		}
+	}
+
+	return counts
+}
+
+// findRules returns the rules for the transaction's subcategory that match it, or if there are none the rules for its
+// whole category that do
+func findRules(rules []models.RemapRule, transaction models.Transaction) []models.RemapRule {
+	var subcategoryRules, categoryRules []models.RemapRule
+
+	for _, rule := range rules {
+		if rule.OldCategory != transaction.Category || !matchesFilter(rule.Filter, transaction) {
+			continue
+		}
+
+		if rule.OldSubcategory == transaction.SubCategory {
+			subcategoryRules = append(subcategoryRules, rule)
+		}
+		if rule.OldSubcategory == "" {
+			categoryRules = append(categoryRules, rule)
+		}
+	}
+
+	if len(subcategoryRules) > 0 {
+		return subcategoryRules
+	}
+
+	return categoryRules
+}
+
+// matchesFilter reports whether transaction passes filter. A transaction whose timestamp can't be read never passes a
+// filter with dates, so it isn't moved by mistake.
+func matchesFilter(filter models.RemapFilter, transaction models.Transaction) bool {
+	if filter.TransactionType != "" && filter.TransactionType != transaction.TransactionType {
+		return false
+	}
+	if filter.PayerPayee != "" && filter.PayerPayee != transaction.PayerPayeeName {
+		return false
+	}
+
+	if filter.From.IsZero() && filter.To.IsZero() {
+		return true
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, transaction.TransactionTimestamp)
+	if err != nil {
+		return false
+	}
+
+	timestamp = timestamp.UTC()
+	day := time.Date(timestamp.Year(), timestamp.Month(), timestamp.Day(), 0, 0, 0, 0, time.UTC)
+
+	return (filter.From.IsZero() || !day.Before(filter.From)) && (filter.To.IsZero() || !day.After(filter.To))
+}
+
+// findCycle returns the first cycle, in sorted order, of the category and subcategory moves are from and to, or nil
+// if there isn't one
+func findCycle(moves []Move) []string {
+	edges := make(map[string]map[string]bool)
+	for _, move := range moves {
+		from := formatTarget(move.Transaction.TransactionType, move.Transaction.Category, move.Transaction.SubCategory)
+		to := formatTarget(move.Transaction.TransactionType, move.NewCategory, move.NewSubcategory)
+
+		if edges[from] == nil {
+			edges[from] = make(map[string]bool)
+		}
+		edges[from][to] = true
+	}
+
+	nodes := make([]string, 0, len(edges))
+	for node := range edges {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int)
+	var path []string
+
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		switch states[node] {
+		case visiting:
+			for i, pathNode := range path {
+				if pathNode == node {
+					return append(append([]string{}, path[i:]...), node)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		states[node] = visiting
+		path = append(path, node)
+
+		next := make([]string, 0, len(edges[node]))
+		for to := range edges[node] {
+			next = append(next, to)
+		}
+		sort.Strings(next)
+
+		for _, to := range next {
+			if cycle := visit(to); cycle != nil {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		states[node] = visited
+		return nil
+	}
+
+	for _, node := range nodes {
+		if cycle := visit(node); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+func formatTarget(transactionType string, category string, subcategory string) string {
+	return fmt.Sprintf("%s > %s > %s", transactionType, category, subcategory)
+}
+
+func targetExists(categories []models.Category, transactionType string, categoryName string, subcategoryName string, options Options) bool {
+	for _, category := range categories {
+		if category.TransactionType != transactionType || category.Name != categoryName {
+			continue
+		}
+
+		return options.AllowMissingSubcategories || category.HasSubcategory(subcategoryName)
+	}
+
+	return false
+}
+
+func newMissingTargetsError(missingTargets map[MissingTarget]int) *MissingTargetsError {
+	targets := make([]MissingTarget, 0, len(missingTargets))
+	for target, transactions := range missingTargets {
+		target.Transactions = transactions
+		targets = append(targets, target)
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].String() < targets[j].String()
+	})
+
+	return &MissingTargetsError{Targets: targets}
+}
@@ -0,0 +1,199 @@
+//go:build !integrationTest
+
+package remap
+
+import (
+	"categoryModifier/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var categories = []models.Category{
+	{Name: "Eating Out", TransactionType: "expense", Subcategories: []string{"Lunch", "Dinner", "Brunch"}},
+	{Name: "Food", TransactionType: "expense", Subcategories: []string{"Lunch", "Dinner", "Eating Out"}},
+	{Name: "Refunds", TransactionType: "income", Subcategories: []string{"Lunch"}},
+}
+
+func newTransaction(id string, transactionType string, category string, subcategory string) models.Transaction {
+	return models.Transaction{Subquery: id, TransactionType: transactionType, Category: category, SubCategory: subcategory}
+}
+
+func TestPlan(t *testing.T) {
+	t.Run("given subcategory rule, when Plan called, then only transactions in that subcategory moved", func(t *testing.T) {
+		lunch := newTransaction("1", "expense", "Eating Out", "Lunch")
+		dinner := newTransaction("2", "expense", "Eating Out", "Dinner")
+		rule := models.RemapRule{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food", NewSubcategory: "Lunch"}
+
+		moves, err := Plan([]models.Transaction{lunch, dinner}, []models.RemapRule{rule}, categories, Options{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []Move{{Transaction: lunch, Rule: rule, NewCategory: "Food", NewSubcategory: "Lunch"}}, moves)
+	})
+
+	t.Run("given category rule without new subcategory, when Plan called, then transactions keep their subcategory", func(t *testing.T) {
+		dinner := newTransaction("1", "expense", "Eating Out", "Dinner")
+		rule := models.RemapRule{OldCategory: "Eating Out", NewCategory: "Food"}
+
+		moves, err := Plan([]models.Transaction{dinner}, []models.RemapRule{rule}, categories, Options{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.TransactionCategoryUpdate{TransactionId: "1", Category: "Food", Subcategory: "Dinner"}, moves[0].Update())
+	})
+
+	t.Run("given category rule with new subcategory, when Plan called, then every subcategory moved onto it", func(t *testing.T) {
+		transactions := []models.Transaction{
+			newTransaction("1", "expense", "Eating Out", "Lunch"),
+			newTransaction("2", "expense", "Eating Out", "Brunch"),
+		}
+		rule := models.RemapRule{OldCategory: "Eating Out", NewCategory: "Food", NewSubcategory: "Eating Out"}
+
+		moves, err := Plan(transactions, []models.RemapRule{rule}, categories, Options{})
+
+		assert.Nil(t, err)
+		assert.Len(t, moves, 2)
+		for _, move := range moves {
+			assert.Equal(t, "Eating Out", move.NewSubcategory)
+		}
+	})
+
+	t.Run("given subcategory and category rules, when Plan called, then subcategory rule takes precedence", func(t *testing.T) {
+		lunch := newTransaction("1", "expense", "Eating Out", "Lunch")
+		dinner := newTransaction("2", "expense", "Eating Out", "Dinner")
+		categoryRule := models.RemapRule{OldCategory: "Eating Out", NewCategory: "Food"}
+		subcategoryRule := models.RemapRule{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food", NewSubcategory: "Dinner"}
+
+		moves, err := Plan([]models.Transaction{lunch, dinner}, []models.RemapRule{categoryRule, subcategoryRule}, categories, Options{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []Move{
+			{Transaction: lunch, Rule: subcategoryRule, NewCategory: "Food", NewSubcategory: "Dinner"},
+			{Transaction: dinner, Rule: categoryRule, NewCategory: "Food", NewSubcategory: "Dinner"},
+		}, moves)
+	})
+
+	t.Run("given rule that leaves transaction where it is, when Plan called, then transaction not moved", func(t *testing.T) {
+		lunch := newTransaction("1", "expense", "Food", "Lunch")
+
+		moves, err := Plan([]models.Transaction{lunch}, []models.RemapRule{{OldCategory: "Food", NewCategory: "Food"}}, categories, Options{})
+
+		assert.Nil(t, err)
+		assert.Empty(t, moves)
+	})
+
+	t.Run("given targets that don't exist, when Plan called, then nothing planned and every missing target returned", func(t *testing.T) {
+		transactions := []models.Transaction{
+			newTransaction("1", "expense", "Eating Out", "Lunch"),
+			newTransaction("2", "expense", "Eating Out", "Brunch"),
+			newTransaction("3", "expense", "Eating Out", "Brunch"),
+			newTransaction("4", "income", "Eating Out", "Lunch"),
+		}
+
+		moves, err := Plan(transactions, []models.RemapRule{{OldCategory: "Eating Out", NewCategory: "Food"}}, categories, Options{})
+
+		assert.Nil(t, moves)
+		assert.Equal(t, &MissingTargetsError{Targets: []MissingTarget{
+			{TransactionType: "expense", Category: "Food", Subcategory: "Brunch", Transactions: 2},
+			{TransactionType: "income", Category: "Food", Subcategory: "Lunch", Transactions: 1},
+		}}, err)
+		assert.ErrorContains(t, err, "expense > Food > Brunch (2 transactions)")
+	})
+
+	t.Run("given missing subcategories allowed, when Plan called, then only missing categories refused", func(t *testing.T) {
+		brunch := newTransaction("1", "expense", "Eating Out", "Brunch")
+		rule := models.RemapRule{OldCategory: "Eating Out", NewCategory: "Food"}
+
+		moves, err := Plan([]models.Transaction{brunch}, []models.RemapRule{rule}, categories, Options{AllowMissingSubcategories: true})
+		assert.Nil(t, err)
+		assert.Len(t, moves, 1)
+
+		_, err = Plan([]models.Transaction{brunch}, []models.RemapRule{{OldCategory: "Eating Out", NewCategory: "Groceries"}}, categories, Options{AllowMissingSubcategories: true})
+		assert.IsType(t, &MissingTargetsError{}, err)
+	})
+
+	t.Run("given rule with filter, when Plan called, then only transactions passing the filter moved", func(t *testing.T) {
+		transactions := []models.Transaction{
+			{Subquery: "1", TransactionType: "expense", Category: "Eating Out", SubCategory: "Lunch", PayerPayeeName: "Cafe", TransactionTimestamp: "2023-03-31T23:30:00Z"},
+			{Subquery: "2", TransactionType: "expense", Category: "Eating Out", SubCategory: "Lunch", PayerPayeeName: "Cafe", TransactionTimestamp: "2023-04-01T00:30:00+10:00"},
+			{Subquery: "3", TransactionType: "expense", Category: "Eating Out", SubCategory: "Lunch", PayerPayeeName: "Cafe", TransactionTimestamp: "2023-04-01T00:30:00Z"},
+			{Subquery: "4", TransactionType: "expense", Category: "Eating Out", SubCategory: "Lunch", PayerPayeeName: "Bakery", TransactionTimestamp: "2023-02-01T00:30:00Z"},
+			{Subquery: "5", TransactionType: "expense", Category: "Eating Out", SubCategory: "Lunch", PayerPayeeName: "Cafe"},
+		}
+		rule := models.RemapRule{OldCategory: "Eating Out", NewCategory: "Food", Filter: models.RemapFilter{
+			PayerPayee: "Cafe",
+			From:       time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:         time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC),
+		}}
+
+		moves, err := Plan(transactions, []models.RemapRule{rule}, categories, Options{})
+
+		assert.Nil(t, err)
+		var movedIds []string
+		for _, move := range moves {
+			movedIds = append(movedIds, move.Transaction.Subquery)
+		}
+		assert.Equal(t, []string{"1", "2"}, movedIds)
+	})
+
+	t.Run("given rules of the same precedence matching the same transaction, when Plan called, then conflict returned", func(t *testing.T) {
+		transactions := []models.Transaction{
+			newTransaction("1", "expense", "Eating Out", "Lunch"),
+			newTransaction("2", "expense", "Eating Out", "Lunch"),
+			newTransaction("3", "expense", "Eating Out", "Dinner"),
+		}
+		toFood := models.RemapRule{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food"}
+		expensesToFood := models.RemapRule{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food", NewSubcategory: "Dinner", Filter: models.RemapFilter{TransactionType: "expense"}}
+
+		moves, err := Plan(transactions, []models.RemapRule{toFood, expensesToFood, {OldCategory: "Eating Out", NewCategory: "Food"}}, categories, Options{})
+
+		assert.Nil(t, moves)
+		assert.Equal(t, &ConflictingRulesError{Conflicts: []RuleConflict{{Rules: []models.RemapRule{toFood, expensesToFood}, Transactions: 2}}}, err)
+		assert.ErrorContains(t, err, "Eating Out > Lunch -> Food > * and Eating Out > Lunch -> Food > Dinner [type expense] (2 transactions)")
+	})
+
+	t.Run("given rules moving transactions in a cycle, when Plan called, then cycle returned", func(t *testing.T) {
+		transactions := []models.Transaction{
+			newTransaction("1", "expense", "Food", "Lunch"),
+			newTransaction("2", "expense", "Food", "Dinner"),
+		}
+		rules := []models.RemapRule{
+			{OldCategory: "Food", OldSubcategory: "Lunch", NewCategory: "Food", NewSubcategory: "Dinner"},
+			{OldCategory: "Food", OldSubcategory: "Dinner", NewCategory: "Food", NewSubcategory: "Lunch"},
+		}
+
+		moves, err := Plan(transactions, rules, categories, Options{})
+
+		assert.Nil(t, moves)
+		assert.Equal(t, &CyclicRemapError{Cycle: []string{"expense > Food > Dinner", "expense > Food > Lunch", "expense > Food > Dinner"}}, err)
+	})
+
+	t.Run("given rules moving transactions along a chain, when Plan called, then each transaction moved by the rule for where it is", func(t *testing.T) {
+		transactions := []models.Transaction{
+			newTransaction("1", "expense", "Eating Out", "Lunch"),
+			newTransaction("2", "expense", "Food", "Lunch"),
+		}
+		rules := []models.RemapRule{
+			{OldCategory: "Eating Out", NewCategory: "Food"},
+			{OldCategory: "Food", OldSubcategory: "Lunch", NewCategory: "Food", NewSubcategory: "Dinner"},
+		}
+
+		moves, err := Plan(transactions, rules, categories, Options{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "Lunch", moves[0].NewSubcategory)
+		assert.Equal(t, "Dinner", moves[1].NewSubcategory)
+	})
+}
+
+func TestCountByRule(t *testing.T) {
+	t.Run("given moves, when CountByRule called, then moves counted in the order of the rules", func(t *testing.T) {
+		lunchRule := models.RemapRule{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food"}
+		categoryRule := models.RemapRule{OldCategory: "Eating Out", NewCategory: "Food"}
+		unusedRule := models.RemapRule{OldCategory: "Groceries", NewCategory: "Food"}
+
+		counts := CountByRule([]models.RemapRule{lunchRule, categoryRule, unusedRule}, []Move{{Rule: categoryRule}, {Rule: lunchRule}, {Rule: categoryRule}})
+
+		assert.Equal(t, []int{1, 2, 0}, counts)
+	})
+}
@@ -0,0 +1,203 @@
+package remap
+
+import (
+	"bytes"
+	"categoryModifier/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var transactionTypes = []string{"expense", "income"}
+
+// ruleFields are the fields of a rule in a rules file, the keys of a YAML rule and the header of a CSV file
+type ruleFields struct {
+	OldCategory     string `yaml:"oldCategory"`
+	OldSubcategory  string `yaml:"oldSubcategory"`
+	NewCategory     string `yaml:"newCategory"`
+	NewSubcategory  string `yaml:"newSubcategory"`
+	TransactionType string `yaml:"transactionType"`
+	PayerPayee      string `yaml:"payerPayee"`
+	From            string `yaml:"from"`
+	To              string `yaml:"to"`
+}
+
+var csvColumns = []string{"oldCategory", "oldSubcategory", "newCategory", "newSubcategory", "transactionType", "payerPayee", "from", "to"}
+
+type rulesFile struct {
+	Rules []ruleFields `yaml:"rules"`
+}
+
+// LoadRules reads the rules in a .yaml, .yml or .csv rules file. A YAML file has a list of rules under "rules", a CSV
+// file has a header naming its columns. Both use the names oldCategory, oldSubcategory, newCategory, newSubcategory,
+// transactionType, payerPayee, from and to, and only the categories are required.
+func LoadRules(fileName string) ([]models.RemapRule, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []ruleFields
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		fields, err = parseYamlRules(content)
+	case ".csv":
+		fields, err = parseCsvRules(content)
+	default:
+		return nil, fmt.Errorf("%s should be a .yaml, .yml or .csv file", fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fileName, err)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s has no rules", fileName)
+	}
+
+	rules := make([]models.RemapRule, 0, len(fields))
+	for i, ruleFields := range fields {
+		rule, err := ruleFields.toRule()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d in %s: %w", i+1, fileName, err)
+		}
+
+		for j, previousRule := range rules {
+			if sameTransactions(previousRule, rule) {
+				return nil, fmt.Errorf("rule %d in %s moves the same transactions as rule %d: %s", i+1, fileName, j+1, rule)
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func parseYamlRules(content []byte) ([]ruleFields, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	var file rulesFile
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return file.Rules, nil
+}
+
+func parseCsvRules(content []byte) ([]ruleFields, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for _, column := range header {
+		if !contains(csvColumns, strings.TrimSpace(column)) {
+			return nil, fmt.Errorf("unknown column %q, columns should be %s", column, strings.Join(csvColumns, ", "))
+		}
+	}
+
+	fields := make([]ruleFields, 0, len(records)-1)
+	for _, record := range records[1:] {
+		values := make(map[string]string, len(header))
+		for i, column := range header {
+			values[strings.TrimSpace(column)] = strings.TrimSpace(record[i])
+		}
+
+		fields = append(fields, ruleFields{
+			OldCategory:     values["oldCategory"],
+			OldSubcategory:  values["oldSubcategory"],
+			NewCategory:     values["newCategory"],
+			NewSubcategory:  values["newSubcategory"],
+			TransactionType: values["transactionType"],
+			PayerPayee:      values["payerPayee"],
+			From:            values["from"],
+			To:              values["to"],
+		})
+	}
+
+	return fields, nil
+}
+
+func (f ruleFields) toRule() (models.RemapRule, error) {
+	if f.OldCategory == "" || f.NewCategory == "" {
+		return models.RemapRule{}, errors.New("oldCategory and newCategory are required")
+	}
+
+	if f.TransactionType != "" && !contains(transactionTypes, f.TransactionType) {
+		return models.RemapRule{}, fmt.Errorf("transactionType must be one of %s", strings.Join(transactionTypes, ", "))
+	}
+
+	from, err := parseDate("from", f.From)
+	if err != nil {
+		return models.RemapRule{}, err
+	}
+	to, err := parseDate("to", f.To)
+	if err != nil {
+		return models.RemapRule{}, err
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return models.RemapRule{}, errors.New("to is before from")
+	}
+
+	rule := models.RemapRule{
+		OldCategory:    f.OldCategory,
+		OldSubcategory: f.OldSubcategory,
+		NewCategory:    f.NewCategory,
+		NewSubcategory: f.NewSubcategory,
+		Filter: models.RemapFilter{
+			TransactionType: f.TransactionType,
+			PayerPayee:      f.PayerPayee,
+			From:            from,
+			To:              to,
+		},
+	}
+
+	if rule.OldCategory == rule.NewCategory && (rule.NewSubcategory == "" || rule.NewSubcategory == rule.OldSubcategory) {
+		return models.RemapRule{}, fmt.Errorf("%s doesn't move any transactions", rule)
+	}
+
+	return rule, nil
+}
+
+func parseDate(field string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse(models.DateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s should be a date like 2023-12-31", field)
+	}
+
+	return date, nil
+}
+
+// sameTransactions reports whether two rules would match exactly the same transactions
+func sameTransactions(a models.RemapRule, b models.RemapRule) bool {
+	return a.OldCategory == b.OldCategory && a.OldSubcategory == b.OldSubcategory && a.Filter == b.Filter
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+//go:build !integrationTest
+
+package remap
+
+import (
+	"categoryModifier/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRulesFile(t *testing.T, name string, content string) string {
+	fileName := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(fileName, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return fileName
+}
+
+func TestLoadRules(t *testing.T) {
+	expectedRules := []models.RemapRule{
+		{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food", NewSubcategory: "Lunch"},
+		{OldCategory: "Eating Out", NewCategory: "Food", NewSubcategory: "Eating Out", Filter: models.RemapFilter{
+			TransactionType: "expense",
+			PayerPayee:      "Cafe",
+			From:            time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:              time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	t.Run("given YAML file, when LoadRules called, then rules returned", func(t *testing.T) {
+		fileName := writeRulesFile(t, "rules.yaml", `rules:
+  - oldCategory: Eating Out
+    oldSubcategory: Lunch
+    newCategory: Food
+    newSubcategory: Lunch
+  - oldCategory: Eating Out
+    newCategory: Food
+    newSubcategory: Eating Out
+    transactionType: expense
+    payerPayee: Cafe
+    from: 2023-01-01
+    to: 2023-12-31
+`)
+
+		rules, err := LoadRules(fileName)
+
+		assert.Nil(t, err)
+		assert.Equal(t, expectedRules, rules)
+	})
+
+	t.Run("given CSV file, when LoadRules called, then rules returned", func(t *testing.T) {
+		fileName := writeRulesFile(t, "rules.csv", `oldCategory,oldSubcategory,newCategory,newSubcategory,transactionType,payerPayee,from,to
+Eating Out,Lunch,Food,Lunch,,,,
+Eating Out,,Food,Eating Out,expense,Cafe,2023-01-01,2023-12-31
+`)
+
+		rules, err := LoadRules(fileName)
+
+		assert.Nil(t, err)
+		assert.Equal(t, expectedRules, rules)
+	})
+
+	t.Run("given CSV file with only some columns, when LoadRules called, then missing columns empty", func(t *testing.T) {
+		fileName := writeRulesFile(t, "rules.csv", "newCategory, oldCategory\nFood, Eating Out\n")
+
+		rules, err := LoadRules(fileName)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []models.RemapRule{{OldCategory: "Eating Out", NewCategory: "Food"}}, rules)
+	})
+
+	invalidFiles := map[string]struct {
+		name    string
+		content string
+		error   string
+	}{
+		"unknown extension": {
+			name:  "rules.json",
+			error: "should be a .yaml, .yml or .csv file",
+		},
+		"no rules": {
+			name:    "rules.yaml",
+			content: "rules: []\n",
+			error:   "has no rules",
+		},
+		"unknown YAML field": {
+			name:    "rules.yaml",
+			content: "rules:\n  - oldCategory: a\n    newCategory: b\n    payee: Cafe\n",
+			error:   "field payee not found",
+		},
+		"unknown CSV column": {
+			name:    "rules.csv",
+			content: "oldCategory,newCategory,payee\na,b,Cafe\n",
+			error:   `unknown column "payee"`,
+		},
+		"missing new category": {
+			name:    "rules.yaml",
+			content: "rules:\n  - oldCategory: a\n",
+			error:   "rule 1 in",
+		},
+		"unknown transaction type": {
+			name:    "rules.csv",
+			content: "oldCategory,newCategory,transactionType\na,b,transfer\n",
+			error:   "transactionType must be one of expense, income",
+		},
+		"invalid date": {
+			name:    "rules.csv",
+			content: "oldCategory,newCategory,from\na,b,31/12/2023\n",
+			error:   "from should be a date like 2023-12-31",
+		},
+		"to before from": {
+			name:    "rules.csv",
+			content: "oldCategory,newCategory,from,to\na,b,2023-12-31,2023-01-01\n",
+			error:   "to is before from",
+		},
+		"rule that moves nothing": {
+			name:    "rules.csv",
+			content: "oldCategory,oldSubcategory,newCategory,newSubcategory\na,x,a,x\n",
+			error:   "doesn't move any transactions",
+		},
+		"rules for the same transactions": {
+			name:    "rules.csv",
+			content: "oldCategory,oldSubcategory,newCategory\na,x,b\nc,,d\na,x,c\n",
+			error:   "rule 3 in",
+		},
+	}
+	for name, testCase := range invalidFiles {
+		t.Run("given "+name+", when LoadRules called, then error returned", func(t *testing.T) {
+			fileName := writeRulesFile(t, testCase.name, testCase.content)
+
+			_, err := LoadRules(fileName)
+
+			assert.ErrorContains(t, err, testCase.error)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"categoryModifier/models"
+	"categoryModifier/remap"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+var outputs = []string{tableOutput, jsonOutput}
+
+// Report lists the transactions categoryModifier moved, or would move in a dry run, and how many each rule moved
+type Report struct {
+	DryRun       bool        `json:"dryRun"`
+	Changes      []Change    `json:"changes"`
+	Rules        []RuleTotal `json:"rules"`
+	Transactions int         `json:"transactions"`
+	rules        []models.RemapRule
+}
+
+// Change is a transaction moved from one category and subcategory to another. ScopeId is the user or profile the
+// transaction belongs to.
+type Change struct {
+	ScopeId        string `json:"scopeId"`
+	TransactionId  string `json:"transactionId"`
+	Date           string `json:"date"`
+	Amount         string `json:"amount"`
+	PayerPayee     string `json:"payerPayee"`
+	OldCategory    string `json:"oldCategory"`
+	OldSubcategory string `json:"oldSubcategory"`
+	NewCategory    string `json:"newCategory"`
+	NewSubcategory string `json:"newSubcategory"`
+	Rule           string `json:"rule"`
+}
+
+type RuleTotal struct {
+	Rule         string `json:"rule"`
+	Transactions int    `json:"transactions"`
+}
+
+func newReport(params Parameters) Report {
+	report := Report{
+		DryRun:  params.dryRun,
+		Changes: make([]Change, 0),
+		Rules:   make([]RuleTotal, 0, len(params.rules)),
+		rules:   params.rules,
+	}
+
+	for _, rule := range params.rules {
+		report.Rules = append(report.Rules, RuleTotal{Rule: rule.String()})
+	}
+
+	return report
+}
+
+// add records the moves made for the user or profile scopeId
+func (r *Report) add(scopeId string, moves []remap.Move) {
+	for _, move := range moves {
+		r.Changes = append(r.Changes, Change{
+			ScopeId:        scopeId,
+			TransactionId:  move.Transaction.Subquery,
+			Date:           move.Transaction.TransactionTimestamp,
+			Amount:         move.Transaction.Amount,
+			PayerPayee:     move.Transaction.PayerPayeeName,
+			OldCategory:    move.Transaction.Category,
+			OldSubcategory: move.Transaction.SubCategory,
+			NewCategory:    move.NewCategory,
+			NewSubcategory: move.NewSubcategory,
+			Rule:           move.Rule.String(),
+		})
+	}
+
+	for i, count := range remap.CountByRule(r.rules, moves) {
+		r.Rules[i].Transactions += count
+		r.Transactions += count
+	}
+}
+
+// write writes the report in output, one of outputs
+func (r Report) write(w io.Writer, output string, scopes int) error {
+	if output == jsonOutput {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+
+	return r.writeTable(w, scopes)
+}
+
+func (r Report) writeTable(w io.Writer, scopes int) error {
+	if len(r.Changes) > 0 {
+		changes := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(changes, "USER/PROFILE\tTRANSACTION\tDATE\tAMOUNT\tPAYER/PAYEE\tOLD CATEGORY\tNEW CATEGORY")
+		for _, change := range r.Changes {
+			fmt.Fprintf(changes, "%s\t%s\t%s\t%s\t%s\t%s > %s\t%s > %s\n", change.ScopeId, change.TransactionId, change.Date, change.Amount,
+				change.PayerPayee, change.OldCategory, change.OldSubcategory, change.NewCategory, change.NewSubcategory)
+		}
+		if err := changes.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	rules := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(rules, "TRANSACTIONS\tRULE")
+	for _, rule := range r.Rules {
+		fmt.Fprintf(rules, "%d\t%s\n", rule.Transactions, rule.Rule)
+	}
+	if err := rules.Flush(); err != nil {
+		return err
+	}
+
+	verb := "moved"
+	if r.DryRun {
+		verb = "dry run, would move"
+	}
+	_, err := fmt.Fprintf(w, "%s %d transactions of %d users or profiles\n", verb, r.Transactions, scopes)
+	return err
+}
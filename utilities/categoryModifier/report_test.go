@@ -0,0 +1,95 @@
+//go:build !integrationTest
+
+package main
+
+import (
+	"bytes"
+	"categoryModifier/models"
+	"categoryModifier/remap"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReport(dryRun bool) Report {
+	lunchRule := models.RemapRule{OldCategory: "Eating Out", OldSubcategory: "Lunch", NewCategory: "Food"}
+	takeawayRule := models.RemapRule{OldCategory: "Takeaway", NewCategory: "Food", NewSubcategory: "Takeaway"}
+
+	report := newReport(Parameters{dryRun: dryRun, rules: []models.RemapRule{lunchRule, takeawayRule}})
+	report.add("auth0|1", []remap.Move{{
+		Transaction: models.Transaction{
+			Subquery:             "transaction-1",
+			TransactionTimestamp: "2023-04-01T12:00:00Z",
+			Amount:               "12.5",
+			Category:             "Eating Out",
+			SubCategory:          "Lunch",
+			PayerPayeeName:       "Cafe",
+		},
+		Rule:           lunchRule,
+		NewCategory:    "Food",
+		NewSubcategory: "Lunch",
+	}})
+	report.add("auth0|2", []remap.Move{})
+
+	return report
+}
+
+func TestReport(t *testing.T) {
+	t.Run("given dry run, when written as table, then changes, totals per rule and dry run summary written", func(t *testing.T) {
+		var stdout bytes.Buffer
+
+		err := newTestReport(true).write(&stdout, tableOutput, 2)
+
+		assert.Nil(t, err)
+		assert.Equal(t, `USER/PROFILE  TRANSACTION    DATE                  AMOUNT  PAYER/PAYEE  OLD CATEGORY        NEW CATEGORY
+auth0|1       transaction-1  2023-04-01T12:00:00Z  12.5    Cafe         Eating Out > Lunch  Food > Lunch
+
+TRANSACTIONS  RULE
+1             Eating Out > Lunch -> Food > *
+0             Takeaway > * -> Food > Takeaway
+dry run, would move 1 transactions of 2 users or profiles
+`, stdout.String())
+	})
+
+	t.Run("given nothing moved, when written as table, then only totals written", func(t *testing.T) {
+		var stdout bytes.Buffer
+
+		err := newReport(Parameters{rules: []models.RemapRule{{OldCategory: "a", NewCategory: "b"}}}).write(&stdout, tableOutput, 1)
+
+		assert.Nil(t, err)
+		assert.NotContains(t, stdout.String(), "TRANSACTION ")
+		assert.Contains(t, stdout.String(), "moved 0 transactions of 1 users or profiles")
+	})
+
+	t.Run("given report, when written as json, then changes and totals per rule written", func(t *testing.T) {
+		var stdout bytes.Buffer
+
+		err := newTestReport(true).write(&stdout, jsonOutput, 2)
+		assert.Nil(t, err)
+
+		var written Report
+		err = json.Unmarshal(stdout.Bytes(), &written)
+		assert.Nil(t, err)
+		assert.Equal(t, Report{
+			DryRun: true,
+			Changes: []Change{{
+				ScopeId:        "auth0|1",
+				TransactionId:  "transaction-1",
+				Date:           "2023-04-01T12:00:00Z",
+				Amount:         "12.5",
+				PayerPayee:     "Cafe",
+				OldCategory:    "Eating Out",
+				OldSubcategory: "Lunch",
+				NewCategory:    "Food",
+				NewSubcategory: "Lunch",
+				Rule:           "Eating Out > Lunch -> Food > *",
+			}},
+			Rules: []RuleTotal{
+				{Rule: "Eating Out > Lunch -> Food > *", Transactions: 1},
+				{Rule: "Takeaway > * -> Food > Takeaway", Transactions: 0},
+			},
+			Transactions: 1,
+		}, written)
+	})
+}
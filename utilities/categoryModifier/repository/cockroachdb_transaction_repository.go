@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"categoryModifier/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CockroachDbConnection is satisfied by *pgx.Conn, *pgxpool.Pool and pgx.Tx
+type CockroachDbConnection interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// CockroachDbMoneyMateDbRepository modifies the transactions of a single profile. Categories are identified by name,
+// a transaction is moved to the category with the new name and the same transaction type as its current one.
+type CockroachDbMoneyMateDbRepository struct {
+	ProfileId  string
+	Connection CockroachDbConnection
+}
+
+type subcategoryKey struct {
+	categoryId string
+	name       string
+}
+
+type categoryKey struct {
+	transactionTypeId string
+	name              string
+}
+
+// GetCategories returns the profile's categories, a category without subcategories has a nil Subcategories
+func (c CockroachDbMoneyMateDbRepository) GetCategories() ([]models.Category, error) {
+	rows, err := c.Connection.Query(context.TODO(),
+		`SELECT c.id, c.name, tt.name, sc.name
+		FROM category c
+		JOIN transactiontype tt ON tt.id = c.transaction_type_id
+		LEFT JOIN subcategory sc ON sc.category_id = c.id
+		WHERE c.profile_id = $1
+		ORDER BY c.sort_order, c.id, sc.sort_order, sc.name`, c.ProfileId)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []models.Category
+	var previousCategoryId, categoryId string
+	var category models.Category
+	var subcategoryName *string
+	_, err = pgx.ForEachRow(rows, []any{&categoryId, &category.Name, &category.TransactionType, &subcategoryName}, func() error {
+		if categoryId != previousCategoryId {
+			categories = append(categories, models.Category{Name: category.Name, TransactionType: category.TransactionType})
+			previousCategoryId = categoryId
+		}
+
+		if subcategoryName != nil {
+			last := &categories[len(categories)-1]
+			last.Subcategories = append(last.Subcategories, *subcategoryName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// GetTransactionsWithCategory returns the profile's transactions in any subcategory of category. UserIdQuery is left
+// empty, it only means something for DynamoDB.
+func (c CockroachDbMoneyMateDbRepository) GetTransactionsWithCategory(category string) ([]models.Transaction, error) {
+	rows, err := c.Connection.Query(context.TODO(),
+		`SELECT t.id, t.transaction_timestamp, tt.name, t.amount::STRING, c.name, sc.name,
+			COALESCE(pp.id::STRING, ''), COALESCE(pp.name, ''), COALESCE(t.notes, '')
+		FROM transaction t
+		JOIN subcategory sc ON sc.id = t.subcategory_id
+		JOIN category c ON c.id = sc.category_id
+		JOIN transactiontype tt ON tt.id = t.transaction_type_id
+		LEFT JOIN payerpayee pp ON pp.id = t.payerpayee_id
+		WHERE t.profile_id = $1 AND c.name = $2
+		ORDER BY t.transaction_timestamp, t.id`, c.ProfileId, category)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []models.Transaction
+	var transaction models.Transaction
+	var transactionTimestamp time.Time
+	_, err = pgx.ForEachRow(rows, []any{
+		&transaction.Subquery, &transactionTimestamp, &transaction.TransactionType, &transaction.Amount, &transaction.Category,
+		&transaction.SubCategory, &transaction.PayerPayeeId, &transaction.PayerPayeeName, &transaction.Note,
+	}, func() error {
+		transaction.TransactionTimestamp = transactionTimestamp.UTC().Format(time.RFC3339)
+		transactions = append(transactions, transaction)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// UpdateTransactionsWithNewCategory moves every transaction in one database transaction, so either all of them are
+// moved or none are. The new category must already exist, subcategories it doesn't have yet are created.
+func (c CockroachDbMoneyMateDbRepository) UpdateTransactionsWithNewCategory(updates []models.TransactionCategoryUpdate) error {
+	ctx := context.TODO()
+
+	return pgx.BeginFunc(ctx, c.Connection, func(tx pgx.Tx) error {
+		categoryIds := make(map[categoryKey]string)
+		subcategoryIds := make(map[subcategoryKey]string)
+
+		for _, update := range updates {
+			var transactionTypeId, subcategoryName string
+			err := tx.QueryRow(ctx,
+				`SELECT t.transaction_type_id, sc.name
+				FROM transaction t
+				JOIN subcategory sc ON sc.id = t.subcategory_id
+				WHERE t.id = $1 AND t.profile_id = $2`, update.TransactionId, c.ProfileId).Scan(&transactionTypeId, &subcategoryName)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("transaction %s does not exist in profile %s", update.TransactionId, c.ProfileId)
+			}
+			if err != nil {
+				return err
+			}
+
+			if update.Subcategory != "" {
+				subcategoryName = update.Subcategory
+			}
+
+			categoryId, err := c.getCategoryId(ctx, tx, categoryIds, categoryKey{transactionTypeId: transactionTypeId, name: update.Category})
+			if err != nil {
+				return err
+			}
+
+			subcategoryId, err := c.getOrCreateSubcategoryId(ctx, tx, subcategoryIds, subcategoryKey{categoryId: categoryId, name: subcategoryName})
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, `UPDATE transaction SET subcategory_id = $1 WHERE id = $2 AND profile_id = $3`,
+				subcategoryId, update.TransactionId, c.ProfileId)
+			if err != nil {
+				return fmt.Errorf("failed to update transaction %s: %w", update.TransactionId, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c CockroachDbMoneyMateDbRepository) getCategoryId(ctx context.Context, tx pgx.Tx, categoryIds map[categoryKey]string, key categoryKey) (string, error) {
+	if categoryId, ok := categoryIds[key]; ok {
+		return categoryId, nil
+	}
+
+	var categoryId string
+	err := tx.QueryRow(ctx,
+		`SELECT id FROM category WHERE profile_id = $1 AND name = $2 AND transaction_type_id = $3`,
+		c.ProfileId, key.name, key.transactionTypeId).Scan(&categoryId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("category %q does not exist in profile %s", key.name, c.ProfileId)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	categoryIds[key] = categoryId
+	return categoryId, nil
+}
+
+// getOrCreateSubcategoryId creates a missing subcategory after the category's existing subcategories
+func (c CockroachDbMoneyMateDbRepository) getOrCreateSubcategoryId(ctx context.Context, tx pgx.Tx, subcategoryIds map[subcategoryKey]string, key subcategoryKey) (string, error) {
+	if subcategoryId, ok := subcategoryIds[key]; ok {
+		return subcategoryId, nil
+	}
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO subcategory (name, category_id, sort_order)
+		SELECT $1, $2, COALESCE(MAX(sort_order) + 1, 0) FROM subcategory WHERE category_id = $2
+		ON CONFLICT (name, category_id) DO NOTHING`, key.name, key.categoryId)
+	if err != nil {
+		return "", fmt.Errorf("failed to create subcategory %q: %w", key.name, err)
+	}
+
+	var subcategoryId string
+	err = tx.QueryRow(ctx, `SELECT id FROM subcategory WHERE name = $1 AND category_id = $2`, key.name, key.categoryId).Scan(&subcategoryId)
+	if err != nil {
+		return "", err
+	}
+
+	subcategoryIds[key] = subcategoryId
+	return subcategoryId, nil
+}
@@ -0,0 +1,206 @@
+//go:build integrationTest
+
+package repository
+
+import (
+	"categoryModifier/models"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const cockroachDbConnectionString = "postgresql://root@localhost:26257/moneymate_db_local?sslmode=disable"
+
+type cockroachDbFixture struct {
+	conn      *pgx.Conn
+	userId    string
+	profileId string
+}
+
+func newCockroachDbFixture(t *testing.T) cockroachDbFixture {
+	conn, err := pgx.Connect(context.Background(), cockroachDbConnectionString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(context.Background()) })
+
+	_, err = conn.Exec(context.Background(), "TRUNCATE users, profile, category, subcategory, payerpayee, transaction CASCADE")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixture := cockroachDbFixture{conn: conn}
+	conn.QueryRow(context.Background(), `INSERT INTO users (user_identifier) VALUES ('auth0|integrationTest') RETURNING id`).Scan(&fixture.userId)
+	fixture.profileId = fixture.createProfile()
+
+	return fixture
+}
+
+func (f cockroachDbFixture) createProfile() (profileId string) {
+	f.conn.QueryRow(context.Background(), `INSERT INTO profile (display_name) VALUES ('Default Profile') RETURNING id`).Scan(&profileId)
+	f.conn.Exec(context.Background(), `INSERT INTO userprofile (user_id, profile_id) VALUES ($1, $2)`, f.userId, profileId)
+	return
+}
+
+func (f cockroachDbFixture) createCategory(profileId string, transactionType string, name string) (categoryId string) {
+	f.conn.QueryRow(context.Background(),
+		`INSERT INTO category (name, user_id, profile_id, transaction_type_id)
+		SELECT $1, $2, $3, id FROM transactiontype WHERE name = $4
+		RETURNING id`, name, f.userId, profileId, transactionType).Scan(&categoryId)
+	return
+}
+
+func (f cockroachDbFixture) createSubcategory(categoryId string, name string) (subcategoryId string) {
+	f.conn.QueryRow(context.Background(), `INSERT INTO subcategory (name, category_id) VALUES ($1, $2) RETURNING id`, name, categoryId).Scan(&subcategoryId)
+	return
+}
+
+func (f cockroachDbFixture) createTransaction(profileId string, transactionType string, subcategoryId string) (transactionId string) {
+	f.conn.QueryRow(context.Background(),
+		`INSERT INTO transaction (user_id, profile_id, transaction_timestamp, transaction_type_id, amount, subcategory_id)
+		SELECT $1, $2, now(), id, 12.5, $3 FROM transactiontype WHERE name = $4
+		RETURNING id`, f.userId, profileId, subcategoryId, transactionType).Scan(&transactionId)
+	return
+}
+
+func (f cockroachDbFixture) getCategoryAndSubcategory(transactionId string) (category string, subcategory string) {
+	f.conn.QueryRow(context.Background(),
+		`SELECT c.name, sc.name
+		FROM transaction t
+		JOIN subcategory sc ON sc.id = t.subcategory_id
+		JOIN category c ON c.id = sc.category_id
+		WHERE t.id = $1`, transactionId).Scan(&category, &subcategory)
+	return
+}
+
+func TestCockroachDbMoneyMateDbRepository(t *testing.T) {
+	t.Run("given transactions in category, when GetTransactionsWithCategory called, then only the profile's transactions in that category returned", func(t *testing.T) {
+		fixture := newCockroachDbFixture(t)
+		otherProfileId := fixture.createProfile()
+
+		eatingOutId := fixture.createCategory(fixture.profileId, "expense", "Eating Out")
+		lunchId := fixture.createSubcategory(eatingOutId, "Lunch")
+		transactionId := fixture.createTransaction(fixture.profileId, "expense", lunchId)
+
+		groceriesId := fixture.createCategory(fixture.profileId, "expense", "Groceries")
+		fixture.createTransaction(fixture.profileId, "expense", fixture.createSubcategory(groceriesId, "Supermarket"))
+
+		otherEatingOutId := fixture.createCategory(otherProfileId, "expense", "Eating Out")
+		fixture.createTransaction(otherProfileId, "expense", fixture.createSubcategory(otherEatingOutId, "Lunch"))
+
+		repo := CockroachDbMoneyMateDbRepository{ProfileId: fixture.profileId, Connection: fixture.conn}
+
+		transactions, err := repo.GetTransactionsWithCategory("Eating Out")
+
+		assert.Nil(t, err)
+		assert.Len(t, transactions, 1)
+		assert.Equal(t, transactionId, transactions[0].Subquery)
+		assert.Equal(t, "expense", transactions[0].TransactionType)
+		assert.Equal(t, "12.5", transactions[0].Amount)
+		assert.Equal(t, "Eating Out", transactions[0].Category)
+		assert.Equal(t, "Lunch", transactions[0].SubCategory)
+	})
+
+	t.Run("given categories, when GetCategories called, then the profile's categories returned with their subcategories", func(t *testing.T) {
+		fixture := newCockroachDbFixture(t)
+		otherProfileId := fixture.createProfile()
+
+		eatingOutId := fixture.createCategory(fixture.profileId, "expense", "Eating Out")
+		fixture.createSubcategory(eatingOutId, "Lunch")
+		fixture.createCategory(fixture.profileId, "income", "Salary")
+		fixture.createSubcategory(fixture.createCategory(otherProfileId, "expense", "Groceries"), "Supermarket")
+
+		repo := CockroachDbMoneyMateDbRepository{ProfileId: fixture.profileId, Connection: fixture.conn}
+
+		categories, err := repo.GetCategories()
+
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []models.Category{
+			{Name: "Eating Out", TransactionType: "expense", Subcategories: []string{"Lunch"}},
+			{Name: "Salary", TransactionType: "income"},
+		}, categories)
+	})
+
+	t.Run("given new category, when UpdateTransactionsWithNewCategory called, then transactions moved to matching subcategories which are created when missing", func(t *testing.T) {
+		fixture := newCockroachDbFixture(t)
+
+		eatingOutId := fixture.createCategory(fixture.profileId, "expense", "Eating Out")
+		lunchTransactionId := fixture.createTransaction(fixture.profileId, "expense", fixture.createSubcategory(eatingOutId, "Lunch"))
+		dinnerTransactionId := fixture.createTransaction(fixture.profileId, "expense", fixture.createSubcategory(eatingOutId, "Dinner"))
+		brunchTransactionId := fixture.createTransaction(fixture.profileId, "expense", fixture.createSubcategory(eatingOutId, "Brunch"))
+
+		foodId := fixture.createCategory(fixture.profileId, "expense", "Food")
+		existingLunchId := fixture.createSubcategory(foodId, "Lunch")
+
+		repo := CockroachDbMoneyMateDbRepository{ProfileId: fixture.profileId, Connection: fixture.conn}
+
+		err := repo.UpdateTransactionsWithNewCategory([]models.TransactionCategoryUpdate{
+			{TransactionId: lunchTransactionId, Category: "Food"},
+			{TransactionId: dinnerTransactionId, Category: "Food"},
+			{TransactionId: brunchTransactionId, Category: "Food", Subcategory: "Lunch"},
+		})
+		assert.Nil(t, err)
+
+		for _, transactionId := range []string{lunchTransactionId, brunchTransactionId} {
+			var subcategoryId string
+			fixture.conn.QueryRow(context.Background(), `SELECT subcategory_id FROM transaction WHERE id = $1`, transactionId).Scan(&subcategoryId)
+			assert.Equal(t, existingLunchId, subcategoryId)
+		}
+
+		category, subcategory := fixture.getCategoryAndSubcategory(dinnerTransactionId)
+		assert.Equal(t, "Food", category)
+		assert.Equal(t, "Dinner", subcategory)
+
+		var dinnerSortOrder int
+		fixture.conn.QueryRow(context.Background(), `SELECT sort_order FROM subcategory WHERE category_id = $1 AND name = 'Dinner'`, foodId).Scan(&dinnerSortOrder)
+		assert.Equal(t, 1, dinnerSortOrder)
+	})
+
+	t.Run("given new category that doesn't exist for one of the transactions, when UpdateTransactionsWithNewCategory called, then error returned and no transaction moved", func(t *testing.T) {
+		fixture := newCockroachDbFixture(t)
+
+		eatingOutId := fixture.createCategory(fixture.profileId, "expense", "Eating Out")
+		expenseTransactionId := fixture.createTransaction(fixture.profileId, "expense", fixture.createSubcategory(eatingOutId, "Lunch"))
+		fixture.createCategory(fixture.profileId, "expense", "Food")
+
+		refundsId := fixture.createCategory(fixture.profileId, "income", "Refunds")
+		incomeTransactionId := fixture.createTransaction(fixture.profileId, "income", fixture.createSubcategory(refundsId, "Lunch"))
+
+		repo := CockroachDbMoneyMateDbRepository{ProfileId: fixture.profileId, Connection: fixture.conn}
+
+		err := repo.UpdateTransactionsWithNewCategory([]models.TransactionCategoryUpdate{
+			{TransactionId: expenseTransactionId, Category: "Food"},
+			{TransactionId: incomeTransactionId, Category: "Food"},
+		})
+		assert.ErrorContains(t, err, `category "Food" does not exist`)
+
+		category, _ := fixture.getCategoryAndSubcategory(expenseTransactionId)
+		assert.Equal(t, "Eating Out", category)
+
+		var numberOfFoodSubcategories int
+		fixture.conn.QueryRow(context.Background(),
+			`SELECT COUNT(1) FROM subcategory sc JOIN category c ON c.id = sc.category_id WHERE c.name = 'Food'`).Scan(&numberOfFoodSubcategories)
+		assert.Equal(t, 0, numberOfFoodSubcategories)
+	})
+
+	t.Run("given transaction of another profile, when UpdateTransactionsWithNewCategory called, then error returned and transaction not moved", func(t *testing.T) {
+		fixture := newCockroachDbFixture(t)
+		otherProfileId := fixture.createProfile()
+
+		otherEatingOutId := fixture.createCategory(otherProfileId, "expense", "Eating Out")
+		otherTransactionId := fixture.createTransaction(otherProfileId, "expense", fixture.createSubcategory(otherEatingOutId, "Lunch"))
+		fixture.createCategory(fixture.profileId, "expense", "Food")
+
+		repo := CockroachDbMoneyMateDbRepository{ProfileId: fixture.profileId, Connection: fixture.conn}
+
+		err := repo.UpdateTransactionsWithNewCategory([]models.TransactionCategoryUpdate{
+			{TransactionId: otherTransactionId, Category: "Food"},
+		})
+		assert.ErrorContains(t, err, "does not exist in profile")
+
+		category, _ := fixture.getCategoryAndSubcategory(otherTransactionId)
+		assert.Equal(t, "Eating Out", category)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"categoryModifier/models"
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -12,8 +13,29 @@ import (
 )
 
 type MoneyMateDbRepository interface {
+	// GetCategories returns the categories transactions can be moved to
+	GetCategories() ([]models.Category, error)
 	GetTransactionsWithCategory(category string) ([]models.Transaction, error)
-	UpdateTransactionWithNewCategory(transactionId string, newCategory string) error
+	UpdateTransactionsWithNewCategory(updates []models.TransactionCategoryUpdate) error
+}
+
+// UpdateTransactionsError is returned when some of the transactions could not be updated, Failures is keyed by
+// transaction id
+type UpdateTransactionsError struct {
+	Failures map[string]error
+}
+
+func (e *UpdateTransactionsError) Error() string {
+	return fmt.Sprintf("failed to update %d transactions", len(e.Failures))
+}
+
+// dynamoDbTransactionTypes maps the TransactionType number stored on DynamoDB categories to the transaction type
+var dynamoDbTransactionTypes = map[int]string{0: "expense", 1: "income"}
+
+type dynamoDbCategory struct {
+	Subquery        string
+	TransactionType int
+	Subcategories   []string
 }
 
 type DynamoDbMoneyMateDbRepository struct {
@@ -26,6 +48,48 @@ func (d DynamoDbMoneyMateDbRepository) getTransactionPartitionKey() string {
 	return fmt.Sprintf("%s#Transaction", d.UserId)
 }
 
+// GetCategories reads the user's categories, which are stored under their own partition with the category name as the
+// sort key
+func (d DynamoDbMoneyMateDbRepository) GetCategories() ([]models.Category, error) {
+	paginator := dynamodb.NewQueryPaginator(d.Client, &dynamodb.QueryInput{
+		TableName:              &d.TableName,
+		KeyConditionExpression: aws.String("UserIdQuery = :userIdQuery"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userIdQuery": &types.AttributeValueMemberS{Value: fmt.Sprintf("%s#Categories", d.UserId)},
+		},
+	})
+
+	var categories []models.Category
+
+	for paginator.HasMorePages() {
+		queryOutput, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+
+		var dynamoDbCategories []dynamoDbCategory
+		err = attributevalue.UnmarshalListOfMaps(queryOutput.Items, &dynamoDbCategories)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, category := range dynamoDbCategories {
+			transactionType, ok := dynamoDbTransactionTypes[category.TransactionType]
+			if !ok {
+				return nil, fmt.Errorf("category %q has unknown transaction type %d", category.Subquery, category.TransactionType)
+			}
+
+			categories = append(categories, models.Category{
+				Name:            category.Subquery,
+				TransactionType: transactionType,
+				Subcategories:   category.Subcategories,
+			})
+		}
+	}
+
+	return categories, nil
+}
+
 func (d DynamoDbMoneyMateDbRepository) GetTransactionsWithCategory(category string) ([]models.Transaction, error) {
 	paginator := dynamodb.NewQueryPaginator(d.Client, &dynamodb.QueryInput{
 		TableName:              &d.TableName,
@@ -54,7 +118,51 @@ func (d DynamoDbMoneyMateDbRepository) GetTransactionsWithCategory(category stri
 	return transactions, nil
 }
 
-func (d DynamoDbMoneyMateDbRepository) UpdateTransactionWithNewCategory(transactionId string, newCategory string) error {
+// UpdateTransactionsWithNewCategory updates every transaction concurrently. DynamoDB items are updated one at a time
+// so the transactions that could be updated stay updated when others fail, see UpdateTransactionsError.
+func (d DynamoDbMoneyMateDbRepository) UpdateTransactionsWithNewCategory(updates []models.TransactionCategoryUpdate) error {
+	var failuresMutex sync.Mutex
+	failures := make(map[string]error)
+
+	var wg sync.WaitGroup
+	for _, update := range updates {
+		wg.Add(1)
+
+		go func(update models.TransactionCategoryUpdate) {
+			defer wg.Done()
+			err := d.updateTransactionWithNewCategory(update)
+			if err != nil {
+				failuresMutex.Lock()
+				failures[update.TransactionId] = err
+				failuresMutex.Unlock()
+			}
+		}(update)
+	}
+
+	wg.Wait()
+
+	if len(failures) > 0 {
+		return &UpdateTransactionsError{Failures: failures}
+	}
+
+	return nil
+}
+
+func (d DynamoDbMoneyMateDbRepository) updateTransactionWithNewCategory(update models.TransactionCategoryUpdate) error {
+	updateExpression := "SET Category = :newCategory"
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":newCategory": &types.AttributeValueMemberS{
+			Value: update.Category,
+		},
+	}
+
+	if update.Subcategory != "" {
+		updateExpression += ", SubCategory = :newSubcategory"
+		expressionAttributeValues[":newSubcategory"] = &types.AttributeValueMemberS{
+			Value: update.Subcategory,
+		}
+	}
+
 	_, err := d.Client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
 		TableName: &d.TableName,
 		Key: map[string]types.AttributeValue{
@@ -62,15 +170,11 @@ func (d DynamoDbMoneyMateDbRepository) UpdateTransactionWithNewCategory(transact
 				Value: d.getTransactionPartitionKey(),
 			},
 			"Subquery": &types.AttributeValueMemberS{
-				Value: transactionId,
-			},
-		},
-		UpdateExpression: aws.String("SET Category = :newCategory"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":newCategory": &types.AttributeValueMemberS{
-				Value: newCategory,
+				Value: update.TransactionId,
 			},
 		},
+		UpdateExpression:          aws.String(updateExpression),
+		ExpressionAttributeValues: expressionAttributeValues,
 	})
 
 	return err